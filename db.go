@@ -8,18 +8,41 @@ package main
 // - Best-effort JSON parsing of results; falls back to raw text
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io"
-	"io/ioutil"
+	"math"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
+	"os/signal"
 	"os/user"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -27,69 +50,382 @@ import (
 
 const (
 	defaultAPI = "http://localhost:8000/db?q="
+	version    = "0.1.0"
 )
 
 // Config holds application configuration
 type Config struct {
-	ScrollAcceleration    int `json:"scroll_acceleration"`     // Rows to skip when holding arrow keys
-	ScrollRepeatThreshold int `json:"scroll_repeat_threshold"` // Number of repeats before acceleration kicks in
-	ScrollRepeatTimeoutMs int `json:"scroll_repeat_timeout_ms"` // Milliseconds to detect key repeat
-	PageScrollStep        int `json:"page_scroll_step"`        // Rows to jump for Page Up/Down
-	MaxHistoryEntries     int `json:"max_history_entries"`     // Maximum number of history entries to keep
-	ConnectionCheckSec    int `json:"connection_check_sec"`    // Seconds between connection status checks
-	MaxColumnWidth        int `json:"max_column_width"`        // Maximum width for table columns
+	ScrollAcceleration     int               `json:"scroll_acceleration"`      // Rows to skip when holding arrow keys
+	ScrollRepeatThreshold  int               `json:"scroll_repeat_threshold"`  // Number of repeats before acceleration kicks in
+	ScrollRepeatTimeoutMs  int               `json:"scroll_repeat_timeout_ms"` // Milliseconds to detect key repeat
+	PageScrollStep         int               `json:"page_scroll_step"`         // Rows to jump for Page Up/Down
+	MaxHistoryEntries      int               `json:"max_history_entries"`      // Maximum number of history entries to keep
+	ConnectionCheckSec     int               `json:"connection_check_sec"`     // Seconds between connection status checks
+	MaxColumnWidth         int               `json:"max_column_width"`         // Maximum width for table columns
+	Profiles               map[string]string `json:"profiles"`                 // Named API bases, e.g. {"dev": "http://localhost:8000/db?q="}
+	Profile                string            `json:"profile"`                  // Active profile (empty = default API)
+	MaxConcurrentRequests  int               `json:"max_concurrent_requests"`  // Requests in flight at once when running on all profiles (0 = unlimited)
+	ReadPath               string            `json:"read_path"`                // URL path for read queries, replacing the API base's (empty = unchanged)
+	WritePath              string            `json:"write_path"`               // URL path for data-modifying queries (empty = unchanged)
+	DateFormat             string            `json:"date_format"`              // Go time layout for RFC3339 values (empty = raw)
+	NumberSeparators       bool              `json:"number_separators"`        // Add thousands separators to numbers
+	ZebraStripes           bool              `json:"zebra_stripes"`            // Alternate row background colors in results
+	ZebraColor             string            `json:"zebra_color"`              // Stripe color name (empty = theme contrast color)
+	CacheTTLSec            int               `json:"cache_ttl_sec"`            // Seconds to serve read-only queries from cache (0 = off)
+	CacheDir               string            `json:"cache_dir"`                // Response cache directory (empty = config dir/cache)
+	Method                 string            `json:"method"`                   // HTTP method for queries: "GET" or "POST"
+	PostEncoding           string            `json:"post_encoding"`            // POST body encoding: "form" or "json"
+	Layout                 LayoutConfig      `json:"layout"`                   // Pane sizes, adjusted with Alt-=/Alt--
+	ExampleQueries         []string          `json:"example_queries"`          // Queries suggested while history is empty
+	ExportDir              string            `json:"export_dir"`               // Default directory for exports (empty = current directory)
+	FocusFollowsMouse      bool              `json:"focus_follows_mouse"`      // Focus panes on hover instead of click
+	MaxResponseBytes       int64             `json:"max_response_bytes"`       // Largest response body to read (0 = unlimited)
+	QueryLogPath           string            `json:"query_log_path"`           // Append every executed query to this JSONL file
+	PinnedColumns          int               `json:"pinned_columns"`           // Leading result columns kept visible when scrolling sideways
+	EnterRuns              string            `json:"enter_runs"`               // "enter-runs" or "ctrl-enter-runs" (Enter inserts a newline)
+	HistorySaveIntervalMs  int               `json:"history_save_interval_ms"` // Batch history writes over this interval (0 = save immediately)
+	TransposeMaxRows       int               `json:"transpose_max_rows"`       // Transpose all rows up to this count, else just the selected row
+	RequestTimeoutSec      int               `json:"request_timeout_sec"`      // Abort queries after this many seconds (0 = no limit)
+	FilterCaseSensitive    bool              `json:"filter_case_sensitive"`    // Column filters match case exactly
+	StreamURL              string            `json:"stream_url"`               // ws:// or wss:// endpoint that streams query rows (empty = HTTP)
+	AutoSaveHistory        bool              `json:"auto_save_history"`        // Write every run query to history.json (off = session only)
+	DetailMaxValueLen      int               `json:"detail_max_value_len"`     // Characters of a value shown in the detail view (0 = unlimited)
+	DetailTable            bool              `json:"detail_table"`             // Show the detail view as aligned field | value columns
+	DetailRowHeight        int               `json:"detail_row_height"`        // Rows a long value may wrap onto in the detail table (0 = unlimited)
+	DefaultView            string            `json:"default_view"`             // How results are shown at startup: "table" or "cards" (one field: value card per row)
+	StartupQuery           string            `json:"startup_query"`            // Query run when the TUI starts (empty = none)
+	StartupFocus           string            `json:"startup_focus"`            // Pane focused at startup: "editor" or "results"
+	PrettyRaw              bool              `json:"pretty_raw"`               // Indent JSON responses in the raw view
+	Aliases                map[string]string `json:"aliases"`                  // @name shortcuts expanded to SQL before running
+	DangerousHosts         []string          `json:"dangerous_hosts"`          // Host substrings treated as production
+	StripTrailingSemicolon bool              `json:"strip_trailing_semicolon"` // Trim trailing ; before sending queries
+	StripComments          bool              `json:"strip_comments"`           // Remove -- and /* */ comments before sending queries (they stay in the editor and history)
+	GroupHistoryByDay      bool              `json:"group_history_by_day"`     // Show date separators in the history list
+	HistoryAllProfiles     bool              `json:"history_all_profiles"`     // Show every profile's queries in the history list, not just the active profile's
+	UserAgent              string            `json:"user_agent"`               // User-Agent for API requests (empty = dbx/<version>)
+	ExtraHeaders           map[string]string `json:"extra_headers"`            // Extra headers sent with every API request
+	ExtraParams            map[string]string `json:"extra_params"`             // Extra URL query parameters sent with every API request
+	AutoRefreshSec         int               `json:"auto_refresh_sec"`         // Seconds between re-runs when auto-refresh is on (0 = disabled)
+	ColorRules             []ColorRule       `json:"color_rules"`              // Conditional text colors for result cells; the first matching rule wins
+	LintRules              map[string]bool   `json:"lint_rules"`               // Pre-run warnings to turn off, e.g. {"select-star-large": false}
+	LargeTables            []string          `json:"large_tables"`             // Tables that "SELECT *" without LIMIT warns about
+	ToastTimeoutMs         int               `json:"toast_timeout_ms"`         // How long confirmation toasts stay up (0 = status line only)
+	QueryColumnOrder       bool              `json:"query_column_order"`       // Show columns in SELECT-list order when the query names them
+	ConfirmEditorOverwrite bool              `json:"confirm_editor_overwrite"` // Ask before a history entry replaces an unsaved editor query
+	PrimaryKeyColumn       string            `json:"primary_key_column"`       // Key column for generated UPDATE statements (empty = a column named "id")
+	TruncateMode           string            `json:"truncate_mode"`            // Where long values are cut: "end", "middle" or "front"
+	EllipsisStr            string            `json:"ellipsis"`                 // Marks where a truncated value was cut (may be empty)
+}
+
+// ColorRule colors the cells of a results column whose value meets every condition set
+type ColorRule struct {
+	Column   string   `json:"column"`             // Column name, matched ignoring case
+	Equals   *string  `json:"equals,omitempty"`   // Value as text equals this ("null" for nulls)
+	Contains string   `json:"contains,omitempty"` // Value as text contains this
+	Regex    string   `json:"regex,omitempty"`    // Value as text matches this regular expression
+	Min      *float64 `json:"min,omitempty"`      // Numeric value is at least this
+	Max      *float64 `json:"max,omitempty"`      // Numeric value is at most this
+	Color    string   `json:"color"`              // Color name, e.g. "red" or "#ff8800"
+}
+
+// ruleRegexps caches the compiled ColorRule regular expressions
+var ruleRegexps = map[string]*regexp.Regexp{}
+
+// match reports whether a value meets all of the rule's conditions
+func (r ColorRule) match(v interface{}) bool {
+	s := fmt.Sprintf("%v", v)
+	if v == nil {
+		s = "null"
+	}
+	if r.Equals != nil && s != *r.Equals {
+		return false
+	}
+	if r.Contains != "" && !strings.Contains(s, r.Contains) {
+		return false
+	}
+	if r.Regex != "" {
+		re, ok := ruleRegexps[r.Regex]
+		if !ok {
+			re, _ = regexp.Compile(r.Regex)
+			ruleRegexps[r.Regex] = re
+		}
+		if re == nil || !re.MatchString(s) {
+			return false
+		}
+	}
+	if r.Min != nil || r.Max != nil {
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil || v == nil || (r.Min != nil && n < *r.Min) || (r.Max != nil && n > *r.Max) {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleColor returns the color of the first rule for the column that matches the value
+func ruleColor(rules []ColorRule, column string, v interface{}) (tcell.Color, bool) {
+	for _, r := range rules {
+		if strings.EqualFold(r.Column, column) && r.match(v) {
+			return tcell.GetColor(r.Color), true
+		}
+	}
+	return tcell.ColorDefault, false
+}
+
+// LayoutConfig holds the pane sizes of the TUI
+type LayoutConfig struct {
+	HistoryWidth      int    `json:"history_width"`       // Columns for the history pane
+	EditorHeight      int    `json:"editor_height"`       // Rows for the editor
+	ResultsWeight     int    `json:"results_weight"`      // Flex weight of the results table
+	BottomWeight      int    `json:"bottom_weight"`       // Flex weight of the detail/raw row
+	DetailWeight      int    `json:"detail_weight"`       // Flex weight of the detail view within its row
+	RawWeight         int    `json:"raw_weight"`          // Flex weight of the raw view within its row
+	Collapsed         string `json:"collapsed"`           // Hidden bottom pane: "", "detail" or "raw"
+	HistoryListWeight int    `json:"history_list_weight"` // Flex weight of the history list within its column
+	PreviewWeight     int    `json:"preview_weight"`      // Flex weight of the history preview within its column
+	HidePreview       bool   `json:"hide_preview"`        // Hide the history preview, giving the list the whole column
+}
+
+// DefaultLayout returns the default pane sizes
+func DefaultLayout() LayoutConfig {
+	return LayoutConfig{
+		HistoryWidth:      30,
+		EditorHeight:      5,
+		ResultsWeight:     2,
+		BottomWeight:      1,
+		DetailWeight:      1,
+		RawWeight:         1,
+		HistoryListWeight: 2,
+		PreviewWeight:     1,
+	}
+}
+
+// normalizeLayout replaces out-of-range pane sizes with their defaults
+func normalizeLayout(l LayoutConfig) LayoutConfig {
+	def := DefaultLayout()
+	if l.HistoryWidth < 10 {
+		l.HistoryWidth = def.HistoryWidth
+	}
+	if l.EditorHeight < 3 {
+		l.EditorHeight = def.EditorHeight
+	}
+	if l.ResultsWeight < 1 {
+		l.ResultsWeight = def.ResultsWeight
+	}
+	if l.BottomWeight < 1 {
+		l.BottomWeight = def.BottomWeight
+	}
+	if l.DetailWeight < 1 {
+		l.DetailWeight = def.DetailWeight
+	}
+	if l.RawWeight < 1 {
+		l.RawWeight = def.RawWeight
+	}
+	if l.HistoryListWeight < 1 {
+		l.HistoryListWeight = def.HistoryListWeight
+	}
+	if l.PreviewWeight < 1 {
+		l.PreviewWeight = def.PreviewWeight
+	}
+	if l.Collapsed != "detail" && l.Collapsed != "raw" {
+		l.Collapsed = ""
+	}
+	return l
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() Config {
 	return Config{
-		ScrollAcceleration:    3,
-		ScrollRepeatThreshold: 3,
-		ScrollRepeatTimeoutMs: 150,
-		PageScrollStep:        10,
-		MaxHistoryEntries:     200,
-		ConnectionCheckSec:    5,
-		MaxColumnWidth:        40,
+		ScrollAcceleration:     3,
+		ScrollRepeatThreshold:  3,
+		ScrollRepeatTimeoutMs:  150,
+		PageScrollStep:         10,
+		MaxHistoryEntries:      200,
+		ConnectionCheckSec:     5,
+		MaxColumnWidth:         40,
+		Method:                 "GET",
+		PostEncoding:           "form",
+		Layout:                 DefaultLayout(),
+		StripTrailingSemicolon: true,
+		MaxResponseBytes:       100 << 20,
+		TransposeMaxRows:       5,
+		HistorySaveIntervalMs:  1000,
+		EnterRuns:              "enter-runs",
+		PrettyRaw:              true,
+		StartupFocus:           "editor",
+		DetailMaxValueLen:      200,
+		DetailRowHeight:        5,
+		AutoSaveHistory:        true,
+		MaxConcurrentRequests:  4,
+		AutoRefreshSec:         10,
+		ToastTimeoutMs:         3000,
+		QueryColumnOrder:       true,
+		ConfirmEditorOverwrite: true,
+		DefaultView:            "table",
+		TruncateMode:           "end",
+		EllipsisStr:            "…",
+		ExampleQueries: []string{
+			"select * from Patients limit 10",
+			"select count(*) from Patients",
+		},
 	}
 }
 
-func configPath() (string, error) {
+// configFile returns the path of a file in the dbx config directory
+func configFile(name string) (string, error) {
 	if env := os.Getenv("XDG_CONFIG_HOME"); env != "" {
-		return filepath.Join(env, "dbx", "config.json"), nil
+		return filepath.Join(env, "dbx", name), nil
 	}
 	usr, err := user.Current()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(usr.HomeDir, ".config", "dbx", "config.json"), nil
+	return filepath.Join(usr.HomeDir, ".config", "dbx", name), nil
+}
+
+// apiOverride replaces every profile's API base when set, as --demo does
+var apiOverride string
+
+// profileAPI returns the API base for a profile, falling back to the default API
+func profileAPI(cfg *Config, name string) string {
+	if apiOverride != "" {
+		return apiOverride
+	}
+	if base, ok := cfg.Profiles[name]; ok && base != "" {
+		return base
+	}
+	return defaultAPI
+}
+
+// profileName is how a profile is shown to the user; the empty profile is "default"
+func profileName(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// routeAPI points an API base at the configured read or write path, depending on whether the
+// query may modify data
+func routeAPI(cfg *Config, apiBase, query string) string {
+	path := cfg.ReadPath
+	if isMutatingQuery(query) {
+		path = cfg.WritePath
+	}
+	if path == "" {
+		return apiBase
+	}
+	u, err := url.Parse(apiBase)
+	if err != nil {
+		return apiBase
+	}
+	u.Path = path
+	return u.String()
 }
 
+// isDangerousHost reports whether an API base points at a host listed in DangerousHosts
+func isDangerousHost(cfg *Config, apiBase string) bool {
+	host := apiBase
+	if u, err := url.Parse(apiBase); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	host = strings.ToLower(host)
+	for _, h := range cfg.DangerousHosts {
+		if h != "" && strings.Contains(host, strings.ToLower(h)) {
+			return true
+		}
+	}
+	return false
+}
+
+func configPath() (string, error) {
+	return configFile("config.json")
+}
+
+// loadConfig reads the config file, creating it with the defaults when it is missing. A file
+// that can't be read, parsed or validated is an error, so an F9 reload can keep the current
+// settings; main falls back to the defaults.
 func loadConfig() (*Config, error) {
+	// Start from defaults so settings missing from older files keep their default values
+	cfg := DefaultConfig()
 	p, err := configPath()
 	if err != nil {
-		cfg := DefaultConfig()
 		return &cfg, nil
 	}
-	b, err := ioutil.ReadFile(p)
+	b, err := os.ReadFile(p)
 	if os.IsNotExist(err) {
 		// Create default config file
-		cfg := DefaultConfig()
 		saveConfig(&cfg)
 		return &cfg, nil
 	}
 	if err != nil {
-		cfg := DefaultConfig()
-		return &cfg, nil
+		return nil, err
 	}
-	var cfg Config
 	if err := json.Unmarshal(b, &cfg); err != nil {
-		// If corrupted, return default config
-		defCfg := DefaultConfig()
-		return &defCfg, nil
+		return nil, err
 	}
+	if err := validateConfig(&cfg); err != nil {
+		return nil, err
+	}
+	cfg.Layout = normalizeLayout(cfg.Layout)
 	return &cfg, nil
 }
 
+// validateConfig reports the first setting dbx can't work with
+func validateConfig(cfg *Config) error {
+	for _, f := range []struct {
+		name string
+		val  int
+		min  int
+	}{
+		{"scroll_acceleration", cfg.ScrollAcceleration, 1},
+		{"scroll_repeat_threshold", cfg.ScrollRepeatThreshold, 0},
+		{"scroll_repeat_timeout_ms", cfg.ScrollRepeatTimeoutMs, 0},
+		{"page_scroll_step", cfg.PageScrollStep, 1},
+		{"max_history_entries", cfg.MaxHistoryEntries, 0},
+		{"connection_check_sec", cfg.ConnectionCheckSec, 0},
+		{"max_column_width", cfg.MaxColumnWidth, 1},
+		{"max_concurrent_requests", cfg.MaxConcurrentRequests, 0},
+		{"detail_max_value_len", cfg.DetailMaxValueLen, 0},
+		{"detail_row_height", cfg.DetailRowHeight, 0},
+		{"request_timeout_sec", cfg.RequestTimeoutSec, 0},
+		{"auto_refresh_sec", cfg.AutoRefreshSec, 0},
+		{"toast_timeout_ms", cfg.ToastTimeoutMs, 0},
+	} {
+		if f.val < f.min {
+			return fmt.Errorf("%s must be at least %d, got %d", f.name, f.min, f.val)
+		}
+	}
+	if !strings.EqualFold(cfg.Method, "GET") && !strings.EqualFold(cfg.Method, "POST") {
+		return fmt.Errorf("method must be GET or POST, got %q", cfg.Method)
+	}
+	if cfg.DefaultView != "table" && cfg.DefaultView != "cards" {
+		return fmt.Errorf("default_view must be table or cards, got %q", cfg.DefaultView)
+	}
+	if cfg.TruncateMode != "end" && cfg.TruncateMode != "middle" && cfg.TruncateMode != "front" {
+		return fmt.Errorf("truncate_mode must be end, middle or front, got %q", cfg.TruncateMode)
+	}
+	for rule := range cfg.LintRules {
+		if _, ok := lintRules[rule]; !ok {
+			return fmt.Errorf("lint_rules: unknown rule %q", rule)
+		}
+	}
+	for i, r := range cfg.ColorRules {
+		if r.Column == "" || r.Color == "" {
+			return fmt.Errorf("color_rules[%d] needs a column and a color", i)
+		}
+		if _, err := regexp.Compile(r.Regex); err != nil {
+			return fmt.Errorf("color_rules[%d]: %v", i, err)
+		}
+	}
+	if cfg.Profile != "" {
+		if _, ok := cfg.Profiles[cfg.Profile]; !ok {
+			return fmt.Errorf("profile %q is not in profiles", cfg.Profile)
+		}
+	}
+	return nil
+}
+
 func saveConfig(cfg *Config) error {
 	p, err := configPath()
 	if err != nil {
@@ -102,13 +438,16 @@ func saveConfig(cfg *Config) error {
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(p, b, 0o644)
+	return os.WriteFile(p, b, 0o644)
 }
 
-// HistoryEntry stores a query and timestamp
+// HistoryEntry stores a query, its timestamp and the profile it ran against. Entries
+// without a profile, including those saved before history was kept per profile, belong to
+// the default profile.
 type HistoryEntry struct {
 	Query     string    `json:"query"`
 	Timestamp time.Time `json:"timestamp"`
+	Profile   string    `json:"profile,omitempty"`
 }
 
 // History holds recent queries
@@ -117,14 +456,7 @@ type History struct {
 }
 
 func historyPath() (string, error) {
-	if env := os.Getenv("XDG_CONFIG_HOME"); env != "" {
-		return filepath.Join(env, "dbx", "history.json"), nil
-	}
-	usr, err := user.Current()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(usr.HomeDir, ".config", "dbx", "history.json"), nil
+	return configFile("history.json")
 }
 
 func loadHistory() (*History, error) {
@@ -132,7 +464,7 @@ func loadHistory() (*History, error) {
 	if err != nil {
 		return nil, err
 	}
-	b, err := ioutil.ReadFile(p)
+	b, err := os.ReadFile(p)
 	if os.IsNotExist(err) {
 		return &History{Entries: []HistoryEntry{}}, nil
 	}
@@ -159,670 +491,6737 @@ func saveHistory(h *History) error {
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(p, b, 0o644)
+	return os.WriteFile(p, b, 0o644)
+}
+
+// QueryLogEntry is one line of the query log
+type QueryLogEntry struct {
+	Time       time.Time `json:"time"`
+	Profile    string    `json:"profile,omitempty"`
+	Query      string    `json:"query"`
+	DurationMs int64     `json:"duration_ms"`
+	Rows       int       `json:"rows"`
+	Status     string    `json:"status"` // "ok", "cached" or "error"
+	Error      string    `json:"error,omitempty"`
+}
+
+// appendQueryLog appends an entry to the JSONL query log, if one is configured
+func appendQueryLog(cfg *Config, e QueryLogEntry) error {
+	if cfg.QueryLogPath == "" {
+		return nil
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.QueryLogPath), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(cfg.QueryLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// newQueryLogEntry describes a finished query for the query log
+func newQueryLogEntry(cfg *Config, query string, start time.Time, res interface{}, kind string, cached bool, err error) QueryLogEntry {
+	e := QueryLogEntry{
+		Time:       start,
+		Profile:    cfg.Profile,
+		Query:      query,
+		DurationMs: time.Since(start).Milliseconds(),
+		Rows:       resultRowCount(res, kind),
+		Status:     "ok",
+	}
+	if cached {
+		e.Status = "cached"
+	}
+	if err != nil {
+		e.Status, e.Error = "error", err.Error()
+	}
+	return e
+}
+
+// ColumnOrders maps a result's column signature to the user's preferred column order
+type ColumnOrders map[string][]string
+
+func columnOrdersPath() (string, error) {
+	return configFile("columns.json")
+}
+
+func loadColumnOrders() ColumnOrders {
+	orders := ColumnOrders{}
+	p, err := columnOrdersPath()
+	if err != nil {
+		return orders
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return orders
+	}
+	// if corrupted, fall back to the default order
+	if err := json.Unmarshal(b, &orders); err != nil {
+		return ColumnOrders{}
+	}
+	return orders
+}
+
+func saveColumnOrders(orders ColumnOrders) error {
+	p, err := columnOrdersPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(orders, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, b, 0o644)
 }
 
-// appendHistory appends a query to history, keeping maxLen entries
-func appendHistory(h *History, query string, maxLen int) {
+// appendHistory appends a query run against profile to history, keeping maxLen entries per profile
+func appendHistory(h *History, query, profile string, maxLen int) {
 	query = strings.TrimSpace(query)
 	if query == "" {
 		return
 	}
 	// avoid consecutive duplicates
-	if len(h.Entries) > 0 && h.Entries[0].Query == query {
+	if len(h.Entries) > 0 && h.Entries[0].Query == query && h.Entries[0].Profile == profile {
 		// touch timestamp
 		h.Entries[0].Timestamp = time.Now()
 		return
 	}
-	h.Entries = append([]HistoryEntry{{Query: query, Timestamp: time.Now()}}, h.Entries...)
-	if len(h.Entries) > maxLen {
-		h.Entries = h.Entries[:maxLen]
+	h.Entries = append([]HistoryEntry{{Query: query, Timestamp: time.Now(), Profile: profile}}, h.Entries...)
+	n := 0
+	h.Entries = slices.DeleteFunc(h.Entries, func(e HistoryEntry) bool {
+		if e.Profile != profile {
+			return false
+		}
+		n++
+		return n > maxLen
+	})
+}
+
+// queryURL builds the full request URL for a query against the API base
+func queryURL(apiBase, query string) string {
+	return apiBase + url.QueryEscape(query)
+}
+
+// endpointURL returns the API base without its "?q=" query suffix
+func endpointURL(apiBase string) string {
+	return strings.TrimSuffix(apiBase, "?q=")
+}
+
+// withParams appends the configured extra_params to a URL, after any query it already has
+func withParams(cfg *Config, u string) string {
+	if len(cfg.ExtraParams) == 0 {
+		return u
+	}
+	params := url.Values{}
+	for k, v := range cfg.ExtraParams {
+		params.Set(k, v)
+	}
+	switch {
+	case strings.HasSuffix(u, "?"):
+	case strings.Contains(u, "?"):
+		u += "&"
+	default:
+		u += "?"
 	}
+	return u + params.Encode()
 }
 
-// fetchQuery runs the query against local API and returns parsed data, type, raw response, and error
-func fetchQuery(apiBase, query string) (interface{}, string, string, error) {
-	encoded := url.QueryEscape(query)
-	full := apiBase + encoded
-	resp, err := http.Get(full)
+// healthCheckURL returns the URL the connection check probes: the API base without its query
+// string or fragment, whatever parameter name it ends in
+func healthCheckURL(base string) string {
+	u, err := url.Parse(base)
 	if err != nil {
-		return nil, "", "", err
+		return endpointURL(base)
 	}
-	defer resp.Body.Close()
-	b, err := io.ReadAll(resp.Body)
+	u.RawQuery, u.ForceQuery, u.Fragment = "", false, ""
+	return u.String()
+}
+
+// postBody encodes a query for a POST request, returning the body and its content type
+func postBody(cfg *Config, query string) (string, string) {
+	if cfg.PostEncoding == "json" {
+		b, _ := json.Marshal(map[string]string{"q": query})
+		return string(b), "application/json"
+	}
+	return url.Values{"q": {query}}.Encode(), "application/x-www-form-urlencoded"
+}
+
+// newQueryRequest builds the HTTP request for a query using the configured method and headers
+func newQueryRequest(cfg *Config, apiBase, query string) (*http.Request, error) {
+	apiBase = routeAPI(cfg, apiBase, query)
+	if strings.EqualFold(cfg.Method, "POST") {
+		body, contentType := postBody(cfg, query)
+		req, err := http.NewRequest(http.MethodPost, withParams(cfg, endpointURL(apiBase)), strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		applyHeaders(cfg, req)
+		req.Header.Set("Content-Type", contentType)
+		return req, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, withParams(cfg, queryURL(apiBase, query)), nil)
 	if err != nil {
-		return nil, "", "", err
+		return nil, err
 	}
-	raw := string(b)
-	// try parse JSON
-	var arr []map[string]interface{}
-	if err := json.Unmarshal(b, &arr); err == nil {
-		return arr, "json", raw, nil
+	applyHeaders(cfg, req)
+	return req, nil
+}
+
+// applyHeaders sets the configured User-Agent and extra headers on a request
+func applyHeaders(cfg *Config, req *http.Request) {
+	ua := cfg.UserAgent
+	if ua == "" {
+		ua = "dbx/" + version
 	}
-	// try parse generic JSON
-	var gen interface{}
-	if err := json.Unmarshal(b, &gen); err == nil {
-		return gen, "json", raw, nil
+	req.Header.Set("User-Agent", ua)
+	for k, v := range cfg.ExtraHeaders {
+		req.Header.Set(k, v)
 	}
-	// fallback to raw text
-	return raw, "text", raw, nil
 }
 
-// truncateString truncates a string to maxLen and adds ellipsis if needed
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+// secretHeaderRe matches header and query parameter names whose values are replaced by a
+// placeholder in copied commands and status messages
+var secretHeaderRe = regexp.MustCompile(`(?i)auth|cookie|token|secret|password|key`)
+
+// maskURL replaces a URL's password and secret-looking query parameter values with
+// placeholders, for showing the URL rather than requesting it
+func maskURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	params := strings.Split(u.RawQuery, "&")
+	for i, p := range params {
+		name, _, ok := strings.Cut(p, "=")
+		if k, err := url.QueryUnescape(name); ok && err == nil && secretHeaderRe.MatchString(k) {
+			params[i] = name + "=<" + strings.ToUpper(k) + ">"
+		}
 	}
-	return s[:maxLen-1] + "…"
+	u.RawQuery = strings.Join(params, "&")
+	return u.Redacted()
 }
 
-// renderJSONToTable converts a slice of maps into columns and rows with smart column widths
-func renderJSONToTable(data []map[string]interface{}, table *tview.Table, columns *[]string, cfg *Config) {
-	table.Clear()
-	if len(data) == 0 {
-		return
+// shellQuote quotes a string for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// curlCommand renders the request dbx would send for a query as a curl command line,
+// with secret-looking header values and URL parts replaced by placeholders
+func curlCommand(cfg *Config, apiBase, query string) (string, error) {
+	req, err := newQueryRequest(cfg, apiBase, query)
+	if err != nil {
+		return "", err
 	}
-	// collect column order by first object's keys
-	cols := make([]string, 0, len(data[0]))
-	for k := range data[0] {
-		cols = append(cols, k)
+	parts := []string{"curl", "-X", req.Method}
+	names := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		names = append(names, k)
 	}
-	// Sort columns alphabetically
-	sort.Strings(cols)
-	*columns = cols
-	
-	// Calculate max widths for each column (limit to reasonable sizes)
-	maxColWidth := cfg.MaxColumnWidth
-	const minColWidth = 8
-	colWidths := make(map[string]int)
-	for _, k := range cols {
-		// Start with header width
-		width := len(k)
-		if width < minColWidth {
-			width = minColWidth
-		}
-		// Check first few rows to determine good width
-		for i := 0; i < len(data) && i < 5; i++ {
-			val := fmt.Sprintf("%v", data[i][k])
-			if len(val) > width {
-				width = len(val)
-			}
+	sort.Strings(names)
+	for _, k := range names {
+		v := req.Header.Get(k)
+		if secretHeaderRe.MatchString(k) {
+			v = "<" + strings.ToUpper(k) + ">"
 		}
-		if width > maxColWidth {
-			width = maxColWidth
-		}
-		colWidths[k] = width
+		parts = append(parts, "-H", shellQuote(k+": "+v))
 	}
-	
-	// header - make clickable for sorting
-	for c, k := range cols {
-		cell := tview.NewTableCell(k).SetSelectable(true).SetAttributes(tcell.AttrBold).SetMaxWidth(colWidths[k])
-		table.SetCell(0, c, cell)
-	}
-	// rows
-	for r, row := range data {
-		for c, k := range cols {
-			val := row[k]
-			s := fmt.Sprintf("%v", val)
-			// Truncate if needed
-			if len(s) > colWidths[k] {
-				s = truncateString(s, colWidths[k])
-			}
-			cell := tview.NewTableCell(s).SetMaxWidth(colWidths[k])
-			table.SetCell(r+1, c, cell)
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return "", err
 		}
+		parts = append(parts, "--data-raw", shellQuote(string(body)))
 	}
+	parts = append(parts, shellQuote(maskURL(req.URL.String())))
+	return strings.Join(parts, " "), nil
 }
 
-func main() {
-	// Load configuration
-	cfg, err := loadConfig()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to load config, using defaults: %v\n", err)
-		defCfg := DefaultConfig()
-		cfg = &defCfg
+// openInBrowser opens a URL with the platform's default opener
+func openInBrowser(u string) error {
+	var name string
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		name = "open"
+	case "windows":
+		// cmd /c start would split the URL at & and run the rest as commands
+		name = "rundll32"
+		args = []string{"url.dll,FileProtocolHandler"}
+	default:
+		name = "xdg-open"
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("no browser opener available (%s not found)", name)
 	}
+	return exec.Command(name, append(args, u)...).Start()
+}
 
-	// Check for command-line query argument
-	if len(os.Args) > 1 {
-		// Show usage hint if no valid query detected
-		if len(os.Args) == 2 && (os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help") {
-			fmt.Println("Usage:")
-			fmt.Println("  dbx                    Start interactive TUI")
-			fmt.Println("  dbx 'QUERY'            Execute query and output JSON")
-			fmt.Println("")
-			fmt.Println("Examples:")
-			fmt.Println("  dbx 'select * from Patients limit 1'")
-			fmt.Println("  dbx 'select count(*) from Users'")
-			fmt.Println("")
-			fmt.Println("Note: Quote the entire query to prevent shell expansion of * and other special characters")
-			return
+// maxOSC52Bytes caps what we send through the terminal; many terminals drop larger payloads
+const maxOSC52Bytes = 100000
+
+// copyToClipboard copies text with the platform's clipboard tool, falling back to the
+// terminal's OSC 52 escape in remote sessions and finally to a temp file. It returns
+// where the text went, for the status bar.
+func copyToClipboard(text string) (string, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-copy"})
 		}
-		
-		query := strings.Join(os.Args[1:], " ")
-		data, dataType, raw, err := fetchQuery(defaultAPI, query)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		if os.Getenv("DISPLAY") != "" {
+			candidates = append(candidates, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
 		}
-		
-		// Output based on data type
-		if dataType == "json" {
-			// Pretty print JSON
-			if arr, ok := data.([]map[string]interface{}); ok && len(arr) > 0 {
-				b, err := json.MarshalIndent(arr, "", "  ")
-				if err != nil {
-					fmt.Println(raw)
-				} else {
-					fmt.Println(string(b))
-				}
-			} else {
-				b, err := json.MarshalIndent(data, "", "  ")
-				if err != nil {
-					fmt.Println(raw)
-				} else {
-					fmt.Println(string(b))
-				}
-			}
-		} else {
-			fmt.Println(raw)
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return "clipboard", nil
 		}
-		return
 	}
+	remote := os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+	if remote && len(text) <= maxOSC52Bytes {
+		if err := copyOSC52(text); err == nil {
+			return "terminal clipboard (OSC 52)", nil
+		}
+	}
+	f, err := os.CreateTemp("", "dbx-clipboard-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("no clipboard available: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		return "", fmt.Errorf("no clipboard available: %v", err)
+	}
+	return f.Name() + " (no clipboard available)", nil
+}
 
-	// No arguments - start TUI
-	app := tview.NewApplication()
-
-	// UI components
-	historyList := tview.NewList().ShowSecondaryText(false)
+// copyOSC52 asks the terminal to set its clipboard, wrapping the escape for tmux
+func copyOSC52(text string) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	}
+	_, err = tty.WriteString(seq)
+	return err
+}
+
+// fetchQuery runs the query against local API and returns parsed data, type, raw response, and error
+func fetchQuery(cfg *Config, apiBase, query string) (interface{}, string, string, error) {
+	b, _, err := fetchRaw(cfg, apiBase, query)
+	if err != nil {
+		return nil, "", "", err
+	}
+	res, kind, raw := parseResponse(b)
+	return res, kind, raw, nil
+}
+
+// fetchQueryCached is fetchQuery with the on-disk response cache. Read-only queries are
+// served from the cache within the TTL unless refresh is set. Besides the parsed response
+// it returns the HTTP status (200 for cache hits) and whether the cache was hit.
+// progress, when set, is told about rows as they are decoded from the response.
+func fetchQueryCached(cfg *Config, apiBase, query string, refresh bool, progress rowProgress) (interface{}, string, string, int, bool, error) {
+	useCache := cfg.CacheTTLSec > 0 && !isMutatingQuery(query)
+	if useCache && !refresh {
+		if b, ok := readCache(cfg, apiBase, query); ok {
+			res, kind, raw := parseResponse(b)
+			return res, kind, raw, http.StatusOK, true, nil
+		}
+	}
+	b, rows, code, err := fetchRawProgress(cfg, apiBase, query, progress)
+	if err != nil {
+		return nil, "", "", 0, false, err
+	}
+	if useCache && code >= 200 && code < 300 {
+		// caching is best-effort
+		writeCache(cfg, apiBase, query, b)
+	}
+	if rows != nil {
+		// already decoded while it downloaded
+		return rows, "json", string(b), code, false, nil
+	}
+	res, kind, raw := parseResponse(b)
+	return res, kind, raw, code, false, nil
+}
+
+// queryError records a failed query for the recent errors view
+type queryError struct {
+	Time    time.Time
+	Query   string
+	Code    int // HTTP status, 0 when the request itself failed
+	Message string
+}
+
+// maxRecentErrors caps the recent errors list
+const maxRecentErrors = 20
+
+// fetchRaw requests a query and returns the response body and status code
+func fetchRaw(cfg *Config, apiBase, query string) ([]byte, int, error) {
+	b, _, code, err := fetchRawProgress(cfg, apiBase, query, nil)
+	return b, code, err
+}
+
+// rowProgress receives the number of rows decoded so far, and the first row on the first call
+type rowProgress func(n int, first map[string]interface{})
+
+// fetchRawProgress is fetchRaw that also decodes a JSON array body while it downloads,
+// reporting rows to progress as they arrive. The rows are returned when the whole body was
+// an array of objects, nil otherwise.
+func fetchRawProgress(cfg *Config, apiBase, query string, progress rowProgress) ([]byte, []map[string]interface{}, int, error) {
+	req, err := newQueryRequest(cfg, apiBase, query)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	ctx := context.Background()
+	if cfg.RequestTimeoutSec > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.RequestTimeoutSec)*time.Second)
+		defer cancel()
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer resp.Body.Close()
+	// Read one byte past the cap so an oversized body is detected rather than silently cut
+	var r io.Reader = resp.Body
+	if cfg.MaxResponseBytes > 0 {
+		r = io.LimitReader(resp.Body, cfg.MaxResponseBytes+1)
+	}
+	var buf bytes.Buffer
+	body := io.TeeReader(r, &buf)
+	var rows []map[string]interface{}
+	if progress != nil {
+		rows = streamRows(body, progress)
+	}
+	// Whatever the decoder didn't consume still belongs to the raw body
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return nil, nil, 0, err
+	}
+	if cfg.MaxResponseBytes > 0 && int64(buf.Len()) > cfg.MaxResponseBytes {
+		return nil, nil, 0, fmt.Errorf("response too large (over %d bytes, see max_response_bytes)", cfg.MaxResponseBytes)
+	}
+	return buf.Bytes(), rows, resp.StatusCode, nil
+}
+
+// streamRows decodes a JSON array of objects element by element, reporting progress, and
+// returns the rows. It stops quietly at anything else and returns nil, leaving the caller to
+// parse the full body.
+func streamRows(r io.Reader, progress rowProgress) []map[string]interface{} {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil || t != json.Delim('[') {
+		return nil
+	}
+	rows := []map[string]interface{}{}
+	for n := 1; dec.More(); n++ {
+		var row map[string]interface{}
+		if err := dec.Decode(&row); err != nil {
+			return nil
+		}
+		rows = append(rows, row)
+		if n == 1 {
+			progress(n, row)
+		} else {
+			progress(n, nil)
+		}
+	}
+	// the array must close and be all there is, as parseResponse requires
+	if t, err := dec.Token(); err != nil || t != json.Delim(']') {
+		return nil
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil
+	}
+	return rows
+}
+
+// wsConn is the client side of a WebSocket connection, enough of RFC 6455 to stream query rows
+type wsConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// WebSocket opcodes
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xA
+)
+
+// dialWebSocket opens a WebSocket to a ws:// or wss:// URL, sending the configured headers
+func dialWebSocket(ctx context.Context, cfg *Config, rawURL string) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	addr := u.Host
+	var conn net.Conn
+	switch u.Scheme {
+	case "ws":
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		}
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	case "wss":
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		}
+		conn, err = (&tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}).DialContext(ctx, "tcp", addr)
+	default:
+		return nil, fmt.Errorf("stream_url must start with ws:// or wss://, got %q", rawURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+u.Host+u.RequestURI(), nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	applyHeaders(cfg, req)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	sum := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, fmt.Errorf("stream handshake failed: %s", resp.Status)
+	}
+	return &wsConn{Conn: conn, r: r}, nil
+}
+
+// writeFrame sends a single masked frame, as clients must
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	frame := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	mask := make([]byte, 4)
+	rand.Read(mask)
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := c.Write(frame)
+	return err
+}
+
+// readMessage reads one data message, joining fragments and answering pings; it returns
+// io.EOF when the server closes the stream. Messages over limit bytes (if > 0) are an error.
+func (c *wsConn) readMessage(limit int64) ([]byte, error) {
+	var msg []byte
+	for {
+		var h [2]byte
+		if _, err := io.ReadFull(c.r, h[:]); err != nil {
+			return nil, err
+		}
+		fin, op := h[0]&0x80 != 0, h[0]&0x0F
+		n := uint64(h[1] & 0x7F)
+		switch n {
+		case 126:
+			var b [2]byte
+			if _, err := io.ReadFull(c.r, b[:]); err != nil {
+				return nil, err
+			}
+			n = uint64(binary.BigEndian.Uint16(b[:]))
+		case 127:
+			var b [8]byte
+			if _, err := io.ReadFull(c.r, b[:]); err != nil {
+				return nil, err
+			}
+			n = binary.BigEndian.Uint64(b[:])
+		}
+		var mask [4]byte
+		masked := h[1]&0x80 != 0
+		if masked {
+			if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+				return nil, err
+			}
+		}
+		if n > math.MaxInt64 || op >= wsClose && n > 125 {
+			return nil, fmt.Errorf("invalid stream frame length %d", n)
+		}
+		if limit > 0 && uint64(len(msg))+n > uint64(limit) {
+			return nil, fmt.Errorf("stream message too large (over %d bytes, see max_response_bytes)", limit)
+		}
+		// the buffer grows with the bytes that arrive, not with the length the frame claims
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, c.r, int64(n)); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		payload := buf.Bytes()
+		if masked {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+		switch op {
+		case wsClose:
+			c.writeFrame(wsClose, nil)
+			return nil, io.EOF
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// fetchStream runs a query over the WebSocket at StreamURL: the query goes out as one text
+// message, and each message back holds a row object or an array of rows until the server
+// closes the connection. progress gets the rows so far after every message. Cancelling ctx
+// stops the stream, returning the rows received with ctx's error.
+func fetchStream(ctx context.Context, cfg *Config, query string, progress func([]map[string]interface{})) ([]map[string]interface{}, string, error) {
+	c, err := dialWebSocket(ctx, cfg, cfg.StreamURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer c.Close()
+	stop := context.AfterFunc(ctx, func() { c.Close() })
+	defer stop()
+	if err := c.writeFrame(wsText, []byte(query)); err != nil {
+		return nil, "", err
+	}
+	var rows []map[string]interface{}
+	var raw strings.Builder
+	for {
+		msg, err := c.readMessage(cfg.MaxResponseBytes)
+		if ctx.Err() != nil {
+			return rows, raw.String(), ctx.Err()
+		}
+		if err == io.EOF {
+			return rows, raw.String(), nil
+		}
+		if err != nil {
+			return rows, raw.String(), err
+		}
+		raw.Write(msg)
+		raw.WriteByte('\n')
+		if cfg.MaxResponseBytes > 0 && int64(raw.Len()) > cfg.MaxResponseBytes {
+			return rows, raw.String(), fmt.Errorf("response too large (over %d bytes, see max_response_bytes)", cfg.MaxResponseBytes)
+		}
+		var v interface{}
+		if err := decodeJSON(msg, &v); err != nil {
+			return rows, raw.String(), fmt.Errorf("stream message is not JSON: %v", err)
+		}
+		switch x := v.(type) {
+		case map[string]interface{}:
+			rows = append(rows, x)
+		case []interface{}:
+			rows = append(rows, normalizeRows(x)...)
+		}
+		progress(rows[:len(rows):len(rows)])
+	}
+}
+
+// decodeJSON is json.Unmarshal that keeps numbers as json.Number, so integers of any size
+// and the server's exact number text survive display and export
+func decodeJSON(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("unexpected data after JSON value")
+	}
+	return nil
+}
+
+// parseResponse parses a response body into data, its kind ("json", "malformed" or "text"), and the raw text
+func parseResponse(b []byte) (interface{}, string, string) {
+	raw := string(b)
+	// try parse JSON
+	var arr []map[string]interface{}
+	if err := decodeJSON(b, &arr); err == nil {
+		return arr, "json", raw
+	}
+	// try parse generic JSON
+	var gen interface{}
+	if err := decodeJSON(b, &gen); err == nil {
+		return gen, "json", raw
+	}
+	// body looks like JSON but didn't parse (e.g. truncated)
+	if t := strings.TrimSpace(raw); strings.HasPrefix(t, "{") || strings.HasPrefix(t, "[") {
+		return raw, "malformed", raw
+	}
+	// fallback to raw text
+	return raw, "text", raw
+}
+
+// readOnlyKeywords are statement types that never modify data
+var readOnlyKeywords = map[string]bool{"select": true, "show": true, "explain": true, "describe": true, "desc": true, "values": true}
+
+// writeKeywords are the statements that make a common table expression modify data
+var writeKeywords = map[string]bool{"insert": true, "update": true, "delete": true, "merge": true}
+
+// lintRules describes the pre-run checks lintQuery makes; all are on unless lint_rules turns them off
+var lintRules = map[string]string{
+	"no-where":          "DELETE or UPDATE without WHERE",
+	"select-star-large": "SELECT * without LIMIT on a table listed in large_tables",
+	"drop-truncate":     "DROP or TRUNCATE",
+}
+
+// lintQuery returns warnings about likely mistakes in a query. Only top-level keywords
+// outside comments and strings count, so subqueries and literals don't trigger or hide them.
+func lintQuery(cfg *Config, query string) []string {
+	var words []string
+	depth := 0
+	for _, t := range sqlTokenRe.FindAllString(query, -1) {
+		switch {
+		case t == "(":
+			depth++
+		case t == ")":
+			depth--
+		case depth == 0 && (t == "*" || unicode.IsLetter(rune(t[0])) || t[0] == '_'):
+			words = append(words, strings.ToUpper(t))
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	enabled := func(rule string) bool {
+		on, ok := cfg.LintRules[rule]
+		return on || !ok
+	}
+	table := strings.Trim(tableFromQuery(query, "the table"), `"`)
+	var warnings []string
+	switch words[0] {
+	case "DELETE", "UPDATE":
+		if enabled("no-where") && !slices.Contains(words, "WHERE") {
+			warnings = append(warnings, fmt.Sprintf("%s without WHERE changes every row of %s", words[0], table))
+		}
+	case "SELECT":
+		if enabled("select-star-large") && len(words) > 1 && words[1] == "*" && !slices.Contains(words, "LIMIT") &&
+			slices.ContainsFunc(cfg.LargeTables, func(t string) bool { return strings.EqualFold(t, table) }) {
+			warnings = append(warnings, fmt.Sprintf("SELECT * without LIMIT on %s, a large table", table))
+		}
+	case "DROP", "TRUNCATE":
+		if enabled("drop-truncate") {
+			warnings = append(warnings, fmt.Sprintf("%s can't be undone", words[0]))
+		}
+	}
+	return warnings
+}
+
+// isMutatingQuery reports whether a query may modify data, judged by its first keyword outside
+// comments and parentheses. A WITH query is judged by its main statement, the first top-level
+// word after a CTE body, and counts as a write if any CTE inserts, updates or deletes.
+func isMutatingQuery(query string) bool {
+	var first, main string
+	writes := false
+	depth, afterBody := 0, false
+	for _, t := range sqlTokenRe.FindAllString(query, -1) {
+		switch {
+		case strings.TrimSpace(t) == "" || strings.HasPrefix(t, "--") || strings.HasPrefix(t, "/*"):
+			continue
+		case t == "(":
+			depth++
+		case t == ")":
+			depth--
+		case unicode.IsLetter(rune(t[0])) || t[0] == '_':
+			w := strings.ToLower(t)
+			if first == "" {
+				first = w
+			}
+			writes = writes || writeKeywords[w]
+			// "name (cols) AS (...)" closes a paren before AS too
+			if depth == 0 && afterBody && w != "as" && main == "" {
+				main = w
+			}
+		}
+		afterBody = t == ")" && depth == 0
+	}
+	if first == "" {
+		return false
+	}
+	if first != "with" {
+		return !readOnlyKeywords[first]
+	}
+	return writes || !readOnlyKeywords[main]
+}
+
+// cacheDir returns the response cache directory
+func cacheDir(cfg *Config) (string, error) {
+	if cfg.CacheDir != "" {
+		return cfg.CacheDir, nil
+	}
+	return configFile("cache")
+}
+
+// cacheFile returns the cache path for a query, keyed by a hash of the API base and query
+func cacheFile(cfg *Config, apiBase, query string) (string, error) {
+	dir, err := cacheDir(cfg)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(apiBase + "\x00" + query))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// readCache returns a cached response if it is younger than the TTL
+func readCache(cfg *Config, apiBase, query string) ([]byte, bool) {
+	p, err := cacheFile(cfg, apiBase, query)
+	if err != nil {
+		return nil, false
+	}
+	info, err := os.Stat(p)
+	if err != nil || time.Since(info.ModTime()) > time.Duration(cfg.CacheTTLSec)*time.Second {
+		return nil, false
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// writeCache stores a response in the cache
+func writeCache(cfg *Config, apiBase, query string, b []byte) error {
+	p, err := cacheFile(cfg, apiBase, query)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, b, 0o644)
+}
+
+// scalarColumn is the column name used when a result is an array of scalars
+const scalarColumn = "value"
+
+// normalizeRows converts a generic JSON array into table rows. Objects are kept
+// as-is; an array made only of scalars becomes a single "value" column.
+func normalizeRows(items []interface{}) []map[string]interface{} {
+	maps := make([]map[string]interface{}, 0, len(items))
+	scalars := true
+	for _, item := range items {
+		switch m := item.(type) {
+		case map[string]interface{}:
+			maps = append(maps, m)
+			scalars = false
+		case []interface{}:
+			// nested arrays are not tabular
+			scalars = false
+		}
+	}
+	if len(maps) == 0 && scalars {
+		for _, item := range items {
+			maps = append(maps, map[string]interface{}{scalarColumn: item})
+		}
+	}
+	return maps
+}
+
+// fullValue renders a value without truncation; objects and arrays are shown as indented JSON
+func fullValue(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		if b, err := json.MarshalIndent(v, "", "  "); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// base64Re matches padded standard or URL-safe base64
+var base64Re = regexp.MustCompile(`^[A-Za-z0-9+/_-]+={0,2}$`)
+
+// blobEncoding returns the encoding of a string value that is very likely base64: at least 64
+// characters in whole 4-character groups, mixing upper case, lower case and digits, and in
+// one alphabet only, so it decodes cleanly. Shorter or looser strings (ids, hex digests,
+// words) are left alone.
+func blobEncoding(v interface{}) (string, *base64.Encoding, bool) {
+	s, ok := v.(string)
+	if !ok || len(s) < 64 || len(s)%4 != 0 || !base64Re.MatchString(s) {
+		return "", nil, false
+	}
+	if !strings.ContainsAny(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") || !strings.ContainsAny(s, "abcdefghijklmnopqrstuvwxyz") || !strings.ContainsAny(s, "0123456789") {
+		return "", nil, false
+	}
+	if !strings.ContainsAny(s, "-_") {
+		return s, base64.StdEncoding, true
+	}
+	if strings.ContainsAny(s, "+/") {
+		return "", nil, false
+	}
+	return s, base64.URLEncoding, true
+}
+
+// decodeBlob returns the bytes of a value blobEncoding accepts
+func decodeBlob(v interface{}) ([]byte, bool) {
+	s, enc, ok := blobEncoding(v)
+	if !ok {
+		return nil, false
+	}
+	b, err := enc.DecodeString(s)
+	return b, err == nil
+}
+
+// blobSize returns the decoded size of a value blobEncoding accepts without decoding it, for
+// display where only the size is shown
+func blobSize(v interface{}) (int, bool) {
+	s, _, ok := blobEncoding(v)
+	if !ok {
+		return 0, false
+	}
+	return len(s)/4*3 - (len(s) - len(strings.TrimRight(s, "="))), true
+}
+
+// formatSize renders a byte count as B, KB or MB
+func formatSize(n int) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+}
+
+// blobSummary stands in for a base64 value of size decoded bytes in the table and detail view
+func blobSummary(size int) string {
+	return fmt.Sprintf("[base64, %s]", formatSize(size))
+}
+
+// blobPreview renders decoded bytes as text when they are printable UTF-8, or as a hex dump
+func blobPreview(b []byte, hexDump bool) string {
+	if !hexDump && utf8.Valid(b) && !strings.ContainsFunc(string(b), func(r rune) bool {
+		return !unicode.IsPrint(r) && !unicode.IsSpace(r)
+	}) {
+		return string(b)
+	}
+	const limit = 64 << 10
+	if len(b) > limit {
+		return hex.Dump(b[:limit]) + fmt.Sprintf("… %s more", formatSize(len(b)-limit))
+	}
+	return hex.Dump(b)
+}
+
+// asJSON returns the structured form of a value that is a JSON object or array,
+// either already decoded or embedded as a string
+func asJSON(v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case map[string]interface{}, []interface{}:
+		return x, true
+	case string:
+		t := strings.TrimSpace(x)
+		if !strings.HasPrefix(t, "{") && !strings.HasPrefix(t, "[") {
+			return nil, false
+		}
+		var parsed interface{}
+		if err := decodeJSON([]byte(t), &parsed); err == nil {
+			return parsed, true
+		}
+	}
+	return nil, false
+}
+
+// highlightJSON pretty-prints a decoded JSON value with tview color tags
+func highlightJSON(v interface{}, indent string) string {
+	var b strings.Builder
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if len(x) == 0 {
+			return "{}"
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("{\n")
+		for i, k := range keys {
+			b.WriteString(indent + "  [aqua]" + tview.Escape(strconv.Quote(k)) + "[white]: " + highlightJSON(x[k], indent+"  "))
+			if i < len(keys)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "}")
+	case []interface{}:
+		if len(x) == 0 {
+			return tview.Escape("[]")
+		}
+		b.WriteString("[\n")
+		for i, item := range x {
+			b.WriteString(indent + "  " + highlightJSON(item, indent+"  "))
+			if i < len(x)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "]")
+	case string:
+		b.WriteString("[green]" + tview.Escape(strconv.Quote(x)) + "[white]")
+	case json.Number:
+		b.WriteString("[fuchsia]" + x.String() + "[white]")
+	case float64:
+		b.WriteString("[fuchsia]" + strconv.FormatFloat(x, 'f', -1, 64) + "[white]")
+	default:
+		// booleans and null
+		b.WriteString("[orange]" + jsonText(x) + "[white]")
+	}
+	return b.String()
+}
+
+// jsonText renders a JSON scalar the way it appears in JSON
+func jsonText(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// jsonSummary describes a collapsed JSON value
+func jsonSummary(v interface{}) string {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return fmt.Sprintf("{…} %d keys", len(x))
+	case []interface{}:
+		return tview.Escape(fmt.Sprintf("[…] %d items", len(x)))
+	}
+	return ""
+}
+
+// jsonPath is a path into a JSON value with a short preview of what it points at
+type jsonPath struct {
+	Path    string
+	Preview string
+}
+
+// pathKeyRe matches object keys that can be written as .key in a path
+var pathKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jsonPaths lists the paths of every object field and array element under v, depth first
+// with keys in order, like data.items[0].id; keys that aren't identifiers are quoted
+func jsonPaths(prefix string, v interface{}, out []jsonPath) []jsonPath {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := prefix + "." + k
+			if !pathKeyRe.MatchString(k) {
+				q, _ := json.Marshal(k)
+				p = prefix + "[" + string(q) + "]"
+			}
+			out = append(out, jsonPath{Path: p, Preview: pathPreview(x[k])})
+			out = jsonPaths(p, x[k], out)
+		}
+	case []interface{}:
+		for i, e := range x {
+			p := fmt.Sprintf("%s[%d]", prefix, i)
+			out = append(out, jsonPath{Path: p, Preview: pathPreview(e)})
+			out = jsonPaths(p, e, out)
+		}
+	}
+	return out
+}
+
+// pathPreview summarizes a JSON value for the path picker
+func pathPreview(v interface{}) string {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return fmt.Sprintf("{…} %d keys", len(x))
+	case []interface{}:
+		return fmt.Sprintf("[…] %d items", len(x))
+	case nil:
+		return "null"
+	}
+	b, _ := json.Marshal(v)
+	return truncateString(string(b), 60, "end", "…")
+}
+
+// keyRepeat detects a held arrow key so scrolling can speed up
+type keyRepeat struct {
+	key   tcell.Key
+	at    time.Time
+	count int
+}
+
+// step returns how far a key press should move: 1, or ScrollAcceleration once the same key
+// has repeated more than ScrollRepeatThreshold times within ScrollRepeatTimeoutMs of each other
+func (r *keyRepeat) step(key tcell.Key, now time.Time, cfg *Config) int {
+	// Detect key repeat: if same key pressed within configured timeout, it's a repeat
+	isRepeat := false
+	if key == r.key && now.Sub(r.at) < time.Duration(cfg.ScrollRepeatTimeoutMs)*time.Millisecond {
+		r.count++
+		isRepeat = true
+	} else {
+		r.count = 0
+	}
+	r.key = key
+	r.at = now
+	if isRepeat && r.count > cfg.ScrollRepeatThreshold {
+		return cfg.ScrollAcceleration
+	}
+	return 1
+}
+
+// truncateString shortens s to maxLen characters, cutting its end, middle or front (mode) and
+// marking the cut with ellipsis. Middle truncation keeps both ends, as for IDs and paths.
+func truncateString(s string, maxLen int, mode, ellipsis string) string {
+	r := []rune(s)
+	if len(r) <= maxLen {
+		return s
+	}
+	keep := maxLen - utf8.RuneCountInString(ellipsis)
+	if keep <= 0 {
+		return string(r[:maxLen])
+	}
+	switch mode {
+	case "front":
+		return ellipsis + string(r[len(r)-keep:])
+	case "middle":
+		head := (keep + 1) / 2
+		return string(r[:head]) + ellipsis + string(r[len(r)-(keep-head):])
+	}
+	return string(r[:keep]) + ellipsis
+}
+
+// wrapText splits s into lines of at most width runes, breaking after a space when one is
+// close enough; existing newlines are kept
+func wrapText(s string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		r := []rune(para)
+		for width > 0 && len(r) > width {
+			cut := width
+			if i := strings.LastIndex(string(r[:width]), " "); i > 0 && utf8.RuneCountInString(string(r[:width])[:i]) > width/2 {
+				cut = utf8.RuneCountInString(string(r[:width])[:i]) + 1
+			}
+			lines = append(lines, string(r[:cut]))
+			r = r[cut:]
+		}
+		lines = append(lines, string(r))
+	}
+	return lines
+}
+
+// cellValue renders a value for a results cell; booleans get a check or cross glyph and a color
+func cellValue(v interface{}, cfg *Config) (string, tcell.Color) {
+	if n, ok := blobSize(v); ok {
+		return blobSummary(n), tcell.ColorGray
+	}
+	switch jsonType(v) {
+	case "boolean":
+		if v.(bool) {
+			return "✓ true", tcell.ColorGreen
+		}
+		return "✗ false", tcell.ColorRed
+	}
+	return formatValue(v, cfg), tview.Styles.PrimaryTextColor
+}
+
+// formatValue renders a value for display, applying the opt-in date and number formats
+func formatValue(v interface{}, cfg *Config) string {
+	switch x := v.(type) {
+	case json.Number:
+		if cfg.NumberSeparators {
+			if strings.ContainsAny(x.String(), "eE") {
+				f, _ := x.Float64()
+				return formatNumber(f)
+			}
+			return groupDigits(x.String())
+		}
+	case float64:
+		if cfg.NumberSeparators {
+			return formatNumber(x)
+		}
+	case string:
+		if cfg.DateFormat != "" {
+			if t, err := time.Parse(time.RFC3339Nano, x); err == nil {
+				return t.Format(cfg.DateFormat)
+			}
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// numericValue returns a value as a number if it is a JSON number or a numeric string
+func numericValue(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	case float64:
+		return x, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// columnStats summarizes one column of a result set
+type columnStats struct {
+	Numeric  bool // every non-null value is numeric
+	Boolean  bool // every non-null value is a boolean
+	Count    int  // non-null values
+	True     int
+	False    int
+	Sum      float64
+	Min      float64
+	Max      float64
+	Distinct int
+}
+
+// Avg returns the mean of a numeric column
+func (s columnStats) Avg() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// computeStats aggregates a column over the given rows
+func computeStats(data []map[string]interface{}, col string) columnStats {
+	st := columnStats{Numeric: true, Boolean: true}
+	distinct := make(map[string]bool)
+	for _, row := range data {
+		v := row[col]
+		if v == nil {
+			continue
+		}
+		distinct[fmt.Sprintf("%v", v)] = true
+		st.Count++
+		if b, ok := v.(bool); ok {
+			if b {
+				st.True++
+			} else {
+				st.False++
+			}
+		} else {
+			st.Boolean = false
+		}
+		f, ok := numericValue(v)
+		if !ok {
+			st.Numeric = false
+			continue
+		}
+		if st.Count == 1 || f < st.Min {
+			st.Min = f
+		}
+		if st.Count == 1 || f > st.Max {
+			st.Max = f
+		}
+		st.Sum += f
+	}
+	st.Distinct = len(distinct)
+	if st.Count == 0 {
+		st.Numeric = false
+		st.Boolean = false
+	}
+	return st
+}
+
+// histogramBin is one bar of a column's distribution
+type histogramBin struct {
+	Label string
+	Count int
+}
+
+// columnHistogram counts a column's values: in equal-width buckets when the column is numeric
+// with more than buckets distinct values, otherwise as the top most frequent values with the
+// rest folded into one bin. Nulls get a bin of their own.
+func columnHistogram(data []map[string]interface{}, col string, top, buckets int) []histogramBin {
+	st := computeStats(data, col)
+	nulls := len(data) - st.Count
+	var bins []histogramBin
+	if st.Numeric && st.Distinct > buckets {
+		width := (st.Max - st.Min) / float64(buckets)
+		prec := 0
+		if width < 1 {
+			prec = int(math.Ceil(-math.Log10(width))) + 1
+		} else if width < 10 && width != math.Trunc(width) {
+			prec = 1
+		}
+		bins = make([]histogramBin, buckets)
+		for i := range bins {
+			lo := st.Min + float64(i)*width
+			bins[i].Label = groupDigits(strconv.FormatFloat(lo, 'f', prec, 64)) + " – " + groupDigits(strconv.FormatFloat(lo+width, 'f', prec, 64))
+		}
+		for _, row := range data {
+			f, ok := numericValue(row[col])
+			if row[col] == nil || !ok {
+				continue
+			}
+			i := min(int((f-st.Min)/width), buckets-1)
+			bins[i].Count++
+		}
+	} else {
+		counts := make(map[string]int)
+		for _, row := range data {
+			if v := row[col]; v != nil {
+				counts[fullValue(v)]++
+			}
+		}
+		for label, n := range counts {
+			bins = append(bins, histogramBin{label, n})
+		}
+		sort.Slice(bins, func(i, j int) bool {
+			if bins[i].Count != bins[j].Count {
+				return bins[i].Count > bins[j].Count
+			}
+			return bins[i].Label < bins[j].Label
+		})
+		if len(bins) > top {
+			rest := 0
+			for _, b := range bins[top:] {
+				rest += b.Count
+			}
+			bins = append(bins[:top], histogramBin{fmt.Sprintf("(%d other values)", len(bins)-top), rest})
+		}
+	}
+	if nulls > 0 {
+		bins = append(bins, histogramBin{"null", nulls})
+	}
+	return bins
+}
+
+// histogramBar draws n as a bar of up to width cells scaled to max, in eighths of a cell
+func histogramBar(n, max, width int) string {
+	if max == 0 {
+		return ""
+	}
+	eighths := n * width * 8 / max
+	bar := strings.Repeat("█", eighths/8)
+	if part := eighths % 8; part > 0 {
+		bar += string([]rune("▏▎▍▌▋▊▉")[part-1])
+	}
+	return bar
+}
+
+// historyDayLabel names the day of a history entry for the grouped history list
+func historyDayLabel(t, now time.Time) string {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	switch t := t.In(now.Location()); {
+	case !t.Before(today):
+		return "Today"
+	case !t.Before(today.AddDate(0, 0, -1)):
+		return "Yesterday"
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
+// columnShape describes one column of a result set, like a row of DESCRIBE
+type columnShape struct {
+	Column   string
+	Type     string // JSON type, or types joined with "|" when mixed
+	Nulls    int
+	Distinct int
+	Example  string // first non-null value
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case json.Number, float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// describeColumns infers the type, null count, distinct count and an example for each column
+func describeColumns(data []map[string]interface{}, columns []string) []columnShape {
+	shapes := make([]columnShape, 0, len(columns))
+	for _, col := range columns {
+		sh := columnShape{Column: col}
+		var types []string
+		distinct := make(map[string]bool)
+		for _, row := range data {
+			v := row[col]
+			if v == nil {
+				sh.Nulls++
+				continue
+			}
+			if t := jsonType(v); !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+			s := fmt.Sprintf("%v", v)
+			if sh.Example == "" {
+				sh.Example = s
+			}
+			distinct[s] = true
+		}
+		sh.Distinct = len(distinct)
+		sh.Type = strings.Join(types, "|")
+		if sh.Type == "" {
+			sh.Type = "null"
+		}
+		shapes = append(shapes, sh)
+	}
+	return shapes
+}
+
+// columnFilter keeps rows whose column value satisfies an operator
+type columnFilter struct {
+	Column string
+	Op     string // =, !=, contains, >=, <=, >, <
+	Value  string
+}
+
+// filterOps lists the filter operators, longest first so "!=" wins over "=" and ">=" over ">"
+var filterOps = []string{"contains", "!=", ">=", "<=", "=", ">", "<"}
+
+// parseColumnFilter parses "op value" for a column; a bare value means "="
+func parseColumnFilter(column, expr string) columnFilter {
+	expr = strings.TrimSpace(expr)
+	for _, op := range filterOps {
+		if strings.HasPrefix(strings.ToLower(expr), op) {
+			return columnFilter{Column: column, Op: op, Value: strings.TrimSpace(expr[len(op):])}
+		}
+	}
+	return columnFilter{Column: column, Op: "=", Value: expr}
+}
+
+func (f columnFilter) String() string {
+	return fmt.Sprintf("%s %s %s", f.Column, f.Op, f.Value)
+}
+
+// match reports whether a row passes the filter, comparing numerically when both sides
+// are numbers and otherwise as text, optionally ignoring case
+func (f columnFilter) match(row map[string]interface{}, caseSensitive bool) bool {
+	v := row[f.Column]
+	s := fmt.Sprintf("%v", v)
+	if v == nil {
+		s = "null"
+	}
+	value := f.Value
+	if !caseSensitive {
+		s, value = strings.ToLower(s), strings.ToLower(value)
+	}
+	n, vNum := numericValue(v)
+	want, wantNum := numericValue(f.Value)
+	numeric := vNum && wantNum
+	switch f.Op {
+	case "=":
+		if numeric {
+			return n == want
+		}
+		return s == value
+	case "!=":
+		if numeric {
+			return n != want
+		}
+		return s != value
+	case "contains":
+		return strings.Contains(s, value)
+	case ">":
+		if numeric {
+			return n > want
+		}
+		return v != nil && s > value
+	case "<":
+		if numeric {
+			return n < want
+		}
+		return v != nil && s < value
+	case ">=":
+		if numeric {
+			return n >= want
+		}
+		return v != nil && s >= value
+	case "<=":
+		if numeric {
+			return n <= want
+		}
+		return v != nil && s <= value
+	}
+	return true
+}
+
+// searchColumns returns the positions of the columns whose value contains term
+func searchColumns(row map[string]interface{}, columns []string, term string, caseSensitive bool) []int {
+	var hits []int
+	for i, col := range columns {
+		if (columnFilter{Column: col, Op: "contains", Value: term}).match(row, caseSensitive) {
+			hits = append(hits, i)
+		}
+	}
+	return hits
+}
+
+// filterRows returns the rows passing every filter
+func filterRows(data []map[string]interface{}, filters []columnFilter, caseSensitive bool) []map[string]interface{} {
+	if len(filters) == 0 {
+		return data
+	}
+	var out []map[string]interface{}
+	for _, row := range data {
+		ok := true
+		for _, f := range filters {
+			if !f.match(row, caseSensitive) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			out = append(out, row)
+		}
+	}
+	return out
+}
+
+// sortRows sorts rows in place by the string form of a column
+func sortRows(data []map[string]interface{}, col string, ascending bool) {
+	sort.SliceStable(data, func(i, j int) bool {
+		if ascending {
+			return lessValue(data[i][col], data[j][col])
+		}
+		return lessValue(data[j][col], data[i][col])
+	})
+}
+
+// lessValue orders two cell values; booleans sort false before true, everything else by text
+func lessValue(a, b interface{}) bool {
+	ba, aok := a.(bool)
+	bb, bok := b.(bool)
+	if aok && bok {
+		return !ba && bb
+	}
+	return fmt.Sprintf("%v", a) < fmt.Sprintf("%v", b)
+}
+
+// formatNumber formats a number with comma thousands separators
+func formatNumber(f float64) string {
+	return groupDigits(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// groupDigits adds comma thousands separators to a plain decimal number
+func groupDigits(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i:]
+	}
+	var b strings.Builder
+	for i, d := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String() + frac
+}
+
+// dataColumns returns the first row's keys in alphabetical order
+func dataColumns(data []map[string]interface{}) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	cols := make([]string, 0, len(data[0]))
+	for k := range data[0] {
+		cols = append(cols, k)
+	}
+	sort.Strings(cols)
+	return cols
+}
+
+// orderColumns puts the columns named in order first, followed by the rest in their existing order
+func orderColumns(cols, order []string) []string {
+	present := make(map[string]bool, len(cols))
+	for _, c := range cols {
+		present[c] = true
+	}
+	out := make([]string, 0, len(cols))
+	placed := make(map[string]bool, len(cols))
+	for _, c := range order {
+		if present[c] && !placed[c] {
+			out = append(out, c)
+			placed[c] = true
+		}
+	}
+	for _, c := range cols {
+		if !placed[c] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// headerCell builds a bold, selectable results header cell
+func headerCell(name string, width int) *tview.TableCell {
+	return tview.NewTableCell(name).SetSelectable(true).SetAttributes(tcell.AttrBold).SetMaxWidth(width)
+}
+
+// lastSelectableRow returns the last table row that can be selected, skipping the rows drawn
+// under the data (removed diff rows, the aggregate footer)
+func lastSelectableRow(t *tview.Table) int {
+	for r := t.GetRowCount() - 1; r > 0; r-- {
+		if cell := t.GetCell(r, 0); cell != nil && !cell.NotSelectable {
+			return r
+		}
+	}
+	return 0
+}
+
+// selectListRe captures the column list of a simple SELECT ... FROM query
+var selectListRe = regexp.MustCompile(`(?is)^\s*select\s+(?:distinct\s+)?(.*?)\s+from\b`)
+
+// selectColumns returns the output column names of a query with an explicit
+// column list, or nil for SELECT * and expressions it can't name reliably
+func selectColumns(query string) []string {
+	m := selectListRe.FindStringSubmatch(query)
+	if m == nil || strings.ContainsAny(m[1], "*()") {
+		return nil
+	}
+	var cols []string
+	for _, item := range strings.Split(m[1], ",") {
+		fields := strings.Fields(item)
+		if len(fields) == 0 {
+			return nil
+		}
+		// "expr AS alias" and "expr alias" both name the column by the last word
+		name := fields[len(fields)-1]
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		cols = append(cols, strings.Trim(name, `"`+"`"))
+	}
+	return cols
+}
+
+// queryColumnOrder returns the result columns in the order the query's SELECT list names
+// them, matching names ignoring case; nil when the query has no plain column list
+func queryColumnOrder(query string, cols []string) []string {
+	var order []string
+	for _, name := range selectColumns(query) {
+		for _, c := range cols {
+			if strings.EqualFold(c, name) {
+				order = append(order, c)
+				break
+			}
+		}
+	}
+	return order
+}
+
+// transposeRows turns rows into one row per column: a "field" column, then "value" for a
+// single row or "row 1".."row N" for several
+func transposeRows(data []map[string]interface{}, columns []string) []map[string]interface{} {
+	digits := len(strconv.Itoa(len(data)))
+	out := make([]map[string]interface{}, 0, len(columns))
+	for _, col := range columns {
+		row := map[string]interface{}{"field": col}
+		for i, r := range data {
+			if len(data) == 1 {
+				row["value"] = r[col]
+			} else {
+				// zero-padded so the row columns sort in order
+				row[fmt.Sprintf("row %0*d", digits, i+1)] = r[col]
+			}
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+// resultView is the state of the results pane saved while it shows a transposed view
+type resultView struct {
+	data, all []map[string]interface{}
+	filters   []columnFilter
+	sortCol   int
+	sortAsc   bool
+	row       string // rowID of the transposed row, "" when every row is transposed
+}
+
+// rowGroup is the rows sharing one value of a column
+type rowGroup struct {
+	Key   string // the value's JSON type and text, so 1 and "1" group apart
+	Value interface{}
+	Rows  []map[string]interface{}
+}
+
+// groupRows clusters rows by a column's value, in that column's sort order; rows keep their
+// current order within a group
+func groupRows(data []map[string]interface{}, col string) []rowGroup {
+	sorted := slices.Clone(data)
+	sortRows(sorted, col, true)
+	var groups []rowGroup
+	for _, row := range sorted {
+		v := row[col]
+		key := jsonType(v) + ":" + fmt.Sprintf("%v", v)
+		if n := len(groups); n > 0 && groups[n-1].Key == key {
+			groups[n-1].Rows = append(groups[n-1].Rows, row)
+			continue
+		}
+		groups = append(groups, rowGroup{Key: key, Value: v, Rows: []map[string]interface{}{row}})
+	}
+	return groups
+}
+
+// groupView is the state of the results pane while it shows rows grouped by a column
+type groupView struct {
+	Column   string
+	Expanded map[string]bool // by rowGroup.Key
+	Lines    []groupLine     // what each table row below the header shows
+}
+
+// groupLine is a group's header line (Row is nil) or one of its rows
+type groupLine struct {
+	Group rowGroup
+	Row   map[string]interface{}
+	Index int // of Row within the group
+}
+
+// renderJSONToTable converts a slice of maps into columns and rows with smart column widths.
+// Columns listed in order come first; the rest are alphabetical.
+func renderJSONToTable(data []map[string]interface{}, table *tview.Table, columns *[]string, order []string, cfg *Config) {
+	table.Clear()
+	if len(data) == 0 {
+		return
+	}
+	// collect columns from the first object's keys, alphabetically, then apply the preferred order
+	cols := orderColumns(dataColumns(data), order)
+	*columns = cols
+	
+	// Calculate max widths for each column (limit to reasonable sizes)
+	maxColWidth := cfg.MaxColumnWidth
+	const minColWidth = 8
+	colWidths := make(map[string]int)
+	for _, k := range cols {
+		// Start with header width
+		width := len(k)
+		if width < minColWidth {
+			width = minColWidth
+		}
+		// Check first few rows to determine good width
+		for i := 0; i < len(data) && i < 5; i++ {
+			val, _ := cellValue(data[i][k], cfg)
+			if len(val) > width {
+				width = len(val)
+			}
+		}
+		if width > maxColWidth {
+			width = maxColWidth
+		}
+		colWidths[k] = width
+	}
+	
+	// header - make clickable for sorting
+	for c, k := range cols {
+		table.SetCell(0, c, headerCell(k, colWidths[k]))
+	}
+	// stripes use the theme's contrast color unless one is configured
+	stripe := tview.Styles.ContrastBackgroundColor
+	if cfg.ZebraColor != "" {
+		stripe = tcell.GetColor(cfg.ZebraColor)
+	}
+	// rows
+	for r, row := range data {
+		for c, k := range cols {
+			s, color := cellValue(row[k], cfg)
+			if rc, ok := ruleColor(cfg.ColorRules, k, row[k]); ok {
+				color = rc
+			}
+			// Truncate if needed
+			if len(s) > colWidths[k] {
+				s = truncateString(s, colWidths[k], cfg.TruncateMode, cfg.EllipsisStr)
+			}
+			cell := tview.NewTableCell(s).SetMaxWidth(colWidths[k]).SetTextColor(color)
+			if cfg.ZebraStripes && r%2 == 1 {
+				cell.SetBackgroundColor(stripe)
+			}
+			table.SetCell(r+1, c, cell)
+		}
+	}
+}
+
+// rowSelection remembers a selected results row so it can be restored after a re-render
+type rowSelection struct {
+	Key   string // primary-key value of the row, empty if no key column was detected
+	Index int    // data row index, used when there is no key
+	Col   int
+	Shape string // column signature of the result set the selection belongs to
+}
+
+// primaryKeyColumn returns the column that looks like a primary key, or "" if none
+func primaryKeyColumn(cols []string) string {
+	for _, c := range cols {
+		if strings.EqualFold(c, "id") {
+			return c
+		}
+	}
+	return ""
+}
+
+// columnSignature identifies a result shape by its set of columns
+func columnSignature(cols []string) string {
+	sorted := append([]string(nil), cols...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// findSelectedRow returns the data index matching a saved selection, clamped to the data
+func findSelectedRow(data []map[string]interface{}, cols []string, sel rowSelection) int {
+	if pk := primaryKeyColumn(cols); pk != "" && sel.Key != "" {
+		for i, row := range data {
+			if fmt.Sprintf("%v", row[pk]) == sel.Key {
+				return i
+			}
+		}
+	}
+	if sel.Index >= len(data) {
+		return len(data) - 1
+	}
+	if sel.Index < 0 {
+		return 0
+	}
+	return sel.Index
+}
+
+// rowHash returns a stable hash of a row's contents
+func rowHash(row map[string]interface{}) uint64 {
+	// json.Marshal sorts map keys, so equal rows always serialize the same way
+	b, _ := json.Marshal(row)
+	h := fnv.New64a()
+	h.Write(b)
+	return h.Sum64()
+}
+
+// rowDiff classifies a row against a baseline result set
+type rowDiff int
+
+const (
+	diffSame rowDiff = iota
+	diffAdded
+	diffChanged
+)
+
+// diffResult describes how a result set differs from a baseline
+type diffResult struct {
+	Status  []rowDiff // per current row
+	Removed []map[string]interface{}
+	Added   int
+	Changed int
+}
+
+// diffRows compares cur against base, matching rows by the value of key
+func diffRows(base, cur []map[string]interface{}, key string) diffResult {
+	baseByKey := make(map[string]map[string]interface{}, len(base))
+	for _, row := range base {
+		baseByKey[fmt.Sprintf("%v", row[key])] = row
+	}
+	res := diffResult{Status: make([]rowDiff, len(cur))}
+	seen := make(map[string]bool, len(cur))
+	for i, row := range cur {
+		k := fmt.Sprintf("%v", row[key])
+		seen[k] = true
+		old, ok := baseByKey[k]
+		switch {
+		case !ok:
+			res.Status[i] = diffAdded
+			res.Added++
+		case rowHash(old) != rowHash(row):
+			res.Status[i] = diffChanged
+			res.Changed++
+		}
+	}
+	for _, row := range base {
+		if !seen[fmt.Sprintf("%v", row[key])] {
+			res.Removed = append(res.Removed, row)
+		}
+	}
+	return res
+}
+
+// exportFormats lists the serializers available for copying and exporting results
+var exportFormats = []string{"json", "csv", "tsv", "markdown", "insert"}
+
+// serializeRows renders rows in the given format, using cols for column order.
+// table names the target table for INSERT statements.
+func serializeRows(format string, data []map[string]interface{}, cols []string, table string) (string, error) {
+	var b strings.Builder
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	case "csv":
+		w := csv.NewWriter(&b)
+		w.Write(cols)
+		for _, row := range data {
+			rec := make([]string, len(cols))
+			for i, c := range cols {
+				rec[i] = cellText(row[c])
+			}
+			w.Write(rec)
+		}
+		w.Flush()
+		return b.String(), w.Error()
+	case "tsv":
+		// tabs and newlines inside values would break the grid when pasted into a spreadsheet
+		flatten := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ")
+		b.WriteString(strings.Join(cols, "\t") + "\n")
+		for _, row := range data {
+			cells := make([]string, len(cols))
+			for i, c := range cols {
+				cells[i] = flatten.Replace(cellText(row[c]))
+			}
+			b.WriteString(strings.Join(cells, "\t") + "\n")
+		}
+		return b.String(), nil
+	case "markdown":
+		escape := func(s string) string {
+			return strings.ReplaceAll(strings.ReplaceAll(s, "|", "\\|"), "\n", " ")
+		}
+		b.WriteString("| " + strings.Join(cols, " | ") + " |\n")
+		b.WriteString("|" + strings.Repeat(" --- |", len(cols)) + "\n")
+		for _, row := range data {
+			cells := make([]string, len(cols))
+			for i, c := range cols {
+				cells[i] = escape(cellText(row[c]))
+			}
+			b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		}
+		return b.String(), nil
+	case "insert":
+		for _, row := range data {
+			vals := make([]string, len(cols))
+			for i, c := range cols {
+				vals[i] = sqlLiteral(row[c])
+			}
+			fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES (%s);\n", table, strings.Join(cols, ", "), strings.Join(vals, ", "))
+		}
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("unknown format %q", format)
+}
+
+// cellText renders a value as plain text for exports, with null as empty
+func cellText(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fullValue(v)
+}
+
+// sqlLiteral renders a value as a SQL literal
+func sqlLiteral(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if x {
+			return "TRUE"
+		}
+		return "FALSE"
+	case json.Number:
+		return x.String()
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case string:
+		return "'" + strings.ReplaceAll(x, "'", "''") + "'"
+	}
+	b, _ := json.Marshal(v)
+	return "'" + strings.ReplaceAll(string(b), "'", "''") + "'"
+}
+
+// inClause builds "col IN (...)" from a column's distinct non-null values, in row order
+func inClause(col string, data []map[string]interface{}) (string, int) {
+	seen := map[string]bool{}
+	var vals []string
+	for _, row := range data {
+		v, ok := row[col]
+		if !ok || v == nil {
+			continue
+		}
+		lit := sqlLiteral(v)
+		if !seen[lit] {
+			seen[lit] = true
+			vals = append(vals, lit)
+		}
+	}
+	return fmt.Sprintf("%s IN (%s)", quoteIdent(col), strings.Join(vals, ", ")), len(vals)
+}
+
+// identRe matches identifiers that need no quoting
+var identRe = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// quoteIdent double-quotes an identifier unless it is plain lowercase
+func quoteIdent(id string) string {
+	if identRe.MatchString(id) {
+		return id
+	}
+	return `"` + strings.ReplaceAll(id, `"`, `""`) + `"`
+}
+
+// editedLiteral turns text typed as a cell's new value into a SQL literal: NULL stays NULL,
+// numbers and booleans stay bare when the old value was one, everything else is a string
+func editedLiteral(text string, old interface{}) string {
+	if strings.EqualFold(text, "null") {
+		return "NULL"
+	}
+	switch old.(type) {
+	case json.Number, float64:
+		if numberLiteralRe.MatchString(text) {
+			return text
+		}
+	case bool:
+		if strings.EqualFold(text, "true") || strings.EqualFold(text, "false") {
+			return strings.ToUpper(text)
+		}
+	}
+	return sqlLiteral(text)
+}
+
+// updateStatement builds an UPDATE setting col to the literal value on the row whose key
+// column equals keyValue
+func updateStatement(table, col, value, key string, keyValue interface{}) string {
+	return fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s = %s;", table, quoteIdent(col), value, quoteIdent(key), sqlLiteral(keyValue))
+}
+
+// resultSummary describes a result in one line for sharing, e.g. "Query returned 1,234 rows,
+// 8 columns, executed in 142ms against prod." An unknown elapsed time (0) is left out.
+func resultSummary(rows, cols int, elapsed time.Duration, source, query string) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return groupDigits(strconv.Itoa(n)) + " " + unit + "s"
+	}
+	s := fmt.Sprintf("Query returned %s, %s", plural(rows, "row"), plural(cols, "column"))
+	if elapsed > 0 {
+		s += fmt.Sprintf(", executed in %dms", elapsed.Milliseconds())
+	}
+	s += " " + source + "."
+	if query != "" {
+		s += "\n" + query
+	}
+	return s
+}
+
+// literalRe matches SQL literals: single-quoted strings and numbers
+var literalRe = regexp.MustCompile(`'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b`)
+
+// findLiterals returns the byte ranges of literal values in a query
+func findLiterals(query string) [][]int {
+	return literalRe.FindAllStringIndex(query, -1)
+}
+
+// fromTableRe matches the first table name after FROM
+var fromTableRe = regexp.MustCompile(`(?i)\bfrom\s+("[^"]+"|[\w.]+)`)
+
+// tableFromQuery guesses the table a query reads from, or returns fallback
+func tableFromQuery(query, fallback string) string {
+	if m := fromTableRe.FindStringSubmatch(query); m != nil {
+		return m[1]
+	}
+	return fallback
+}
+
+// sqlitePageSize is the page size of exported SQLite files
+const sqlitePageSize = 4096
+
+// sqliteColumnType picks a conservative SQLite type for a column: INTEGER or REAL when every
+// non-null value is a number (booleans count as integers), TEXT otherwise
+func sqliteColumnType(data []map[string]interface{}, col string) string {
+	typ := ""
+	for _, row := range data {
+		t := ""
+		switch v := row[col].(type) {
+		case nil:
+			continue
+		case bool:
+			t = "INTEGER"
+		case json.Number:
+			t = "REAL"
+			if _, err := v.Int64(); err == nil {
+				t = "INTEGER"
+			}
+		case float64:
+			t = "REAL"
+			if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+				t = "INTEGER"
+			}
+		default:
+			return "TEXT"
+		}
+		switch {
+		case typ == "" || typ == t:
+			typ = t
+		case typ == "INTEGER" && t == "REAL", typ == "REAL" && t == "INTEGER":
+			typ = "REAL"
+		}
+	}
+	if typ == "" {
+		return "TEXT"
+	}
+	return typ
+}
+
+// sqliteVarint appends v as a SQLite variable-length integer (big-endian, up to 9 bytes)
+func sqliteVarint(b []byte, v uint64) []byte {
+	var buf [9]byte
+	if v > 0x00FFFFFFFFFFFFFF {
+		buf[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			buf[i] = byte(v&0x7F) | 0x80
+			v >>= 7
+		}
+		return append(b, buf[:]...)
+	}
+	n := 0
+	for {
+		buf[n] = byte(v&0x7F) | 0x80
+		n++
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	buf[0] &= 0x7F
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, buf[i])
+	}
+	return b
+}
+
+// sqliteRecord encodes values (nil, int64, float64 or string) in SQLite's record format
+func sqliteRecord(values []interface{}) []byte {
+	var types, body []byte
+	for _, v := range values {
+		switch x := v.(type) {
+		case nil:
+			types = sqliteVarint(types, 0)
+		case int64:
+			types = sqliteVarint(types, 6)
+			body = binary.BigEndian.AppendUint64(body, uint64(x))
+		case float64:
+			types = sqliteVarint(types, 7)
+			body = binary.BigEndian.AppendUint64(body, math.Float64bits(x))
+		case string:
+			types = sqliteVarint(types, uint64(2*len(x)+13))
+			body = append(body, x...)
+		}
+	}
+	// the header size counts its own varint
+	size := len(types) + 1
+	for len(sqliteVarint(nil, uint64(size)))+len(types) != size {
+		size++
+	}
+	rec := sqliteVarint(nil, uint64(size))
+	rec = append(rec, types...)
+	return append(rec, body...)
+}
+
+// sqliteValue converts a JSON value for a column of the given SQLite type
+func sqliteValue(v interface{}, typ string) interface{} {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case bool:
+		if typ != "TEXT" {
+			if x {
+				return int64(1)
+			}
+			return int64(0)
+		}
+	case json.Number:
+		if typ == "INTEGER" {
+			if n, err := x.Int64(); err == nil {
+				return n
+			}
+		}
+		if typ != "TEXT" {
+			if f, err := x.Float64(); err == nil {
+				return f
+			}
+		}
+		return x.String()
+	case float64:
+		if typ == "INTEGER" {
+			return int64(x)
+		}
+		if typ == "REAL" {
+			return x
+		}
+	case string:
+		return x
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// sqliteFile lays out the pages of a SQLite database; page 1 is reserved for the schema
+type sqliteFile struct {
+	pages [][]byte
+}
+
+func (f *sqliteFile) newPage() (uint32, []byte) {
+	p := make([]byte, sqlitePageSize)
+	f.pages = append(f.pages, p)
+	return uint32(len(f.pages)), p
+}
+
+// leafCell builds a table leaf cell, moving the part of the record that doesn't fit in the
+// page into a chain of overflow pages
+func (f *sqliteFile) leafCell(rowid int64, rec []byte) []byte {
+	cell := sqliteVarint(nil, uint64(len(rec)))
+	cell = sqliteVarint(cell, uint64(rowid))
+	const usable = sqlitePageSize
+	maxLocal := usable - 35
+	if len(rec) <= maxLocal {
+		return append(cell, rec...)
+	}
+	minLocal := (usable-12)*32/255 - 23
+	local := minLocal + (len(rec)-minLocal)%(usable-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	cell = append(cell, rec[:local]...)
+	rest := rec[local:]
+	first, page := f.newPage()
+	cell = binary.BigEndian.AppendUint32(cell, first)
+	for {
+		n := copy(page[4:], rest)
+		rest = rest[n:]
+		if len(rest) == 0 {
+			return cell
+		}
+		next, p := f.newPage()
+		binary.BigEndian.PutUint32(page, next)
+		page = p
+	}
+}
+
+// writeBTreePage fills a b-tree page: its header at offset (100 on page 1), the cell pointers,
+// and the cells packed at the end of the page
+func writeBTreePage(p []byte, offset int, kind byte, cells [][]byte, right uint32) {
+	header := 8
+	if kind == 0x05 {
+		header = 12
+		binary.BigEndian.PutUint32(p[offset+8:], right)
+	}
+	p[offset] = kind
+	binary.BigEndian.PutUint16(p[offset+3:], uint16(len(cells)))
+	end := len(p)
+	for i, c := range cells {
+		end -= len(c)
+		copy(p[end:], c)
+		binary.BigEndian.PutUint16(p[offset+header+2*i:], uint16(end))
+	}
+	binary.BigEndian.PutUint16(p[offset+5:], uint16(end))
+}
+
+// buildTable writes rows into a table b-tree and returns its root page
+func (f *sqliteFile) buildTable(cells [][]byte, rowids []int64) uint32 {
+	type child struct {
+		page uint32
+		key  int64 // largest rowid under the page
+	}
+	// leaves, filled until the next cell and its pointer don't fit
+	var level []child
+	var batch [][]byte
+	used := 8
+	flush := func(key int64) {
+		n, p := f.newPage()
+		writeBTreePage(p, 0, 0x0D, batch, 0)
+		level = append(level, child{n, key})
+		batch, used = nil, 8
+	}
+	for i, c := range cells {
+		if used+len(c)+2 > sqlitePageSize {
+			flush(rowids[i-1])
+		}
+		batch = append(batch, c)
+		used += len(c) + 2
+	}
+	if len(batch) > 0 || len(level) == 0 {
+		key := int64(0)
+		if len(rowids) > 0 {
+			key = rowids[len(rowids)-1]
+		}
+		flush(key)
+	}
+	// interior levels until a single root remains; 12-byte header, cells of at most 13 bytes
+	const fanout = (sqlitePageSize-12)/(13+2) + 1
+	for len(level) > 1 {
+		var next []child
+		for start := 0; start < len(level); {
+			end := min(start+fanout, len(level))
+			// an interior page needs a cell besides its right pointer, so don't leave one child alone
+			if len(level)-end == 1 {
+				end--
+			}
+			group := level[start:end]
+			var icells [][]byte
+			for _, c := range group[:len(group)-1] {
+				cell := binary.BigEndian.AppendUint32(nil, c.page)
+				icells = append(icells, sqliteVarint(cell, uint64(c.key)))
+			}
+			n, p := f.newPage()
+			last := group[len(group)-1]
+			writeBTreePage(p, 0, 0x05, icells, last.page)
+			next = append(next, child{n, last.key})
+			start = end
+		}
+		level = next
+	}
+	return level[0].page
+}
+
+// encodeSQLite builds a SQLite database file holding the rows in one table, with the columns
+// in the given order and types inferred from the values
+func encodeSQLite(table string, data []map[string]interface{}, columns []string) ([]byte, error) {
+	f := &sqliteFile{}
+	f.newPage() // page 1: header and schema
+
+	quote := func(id string) string { return `"` + strings.ReplaceAll(id, `"`, `""`) + `"` }
+	types := make([]string, len(columns))
+	defs := make([]string, len(columns))
+	for i, col := range columns {
+		types[i] = sqliteColumnType(data, col)
+		defs[i] = quote(col) + " " + types[i]
+	}
+	createSQL := fmt.Sprintf("CREATE TABLE %s (%s)", quote(table), strings.Join(defs, ", "))
+
+	cells := make([][]byte, len(data))
+	rowids := make([]int64, len(data))
+	for r, row := range data {
+		values := make([]interface{}, len(columns))
+		for i, col := range columns {
+			values[i] = sqliteValue(row[col], types[i])
+		}
+		rowids[r] = int64(r + 1)
+		cells[r] = f.leafCell(rowids[r], sqliteRecord(values))
+	}
+	root := f.buildTable(cells, rowids)
+
+	schema := f.leafCell(1, sqliteRecord([]interface{}{"table", table, table, int64(root), createSQL}))
+	if len(schema)+2 > sqlitePageSize-100-8 {
+		return nil, fmt.Errorf("too many columns for a SQLite export")
+	}
+	page1 := f.pages[0]
+	writeBTreePage(page1, 100, 0x0D, [][]byte{schema}, 0)
+
+	// database header
+	copy(page1, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(page1[16:], sqlitePageSize)
+	page1[18], page1[19] = 1, 1                                  // legacy journal file format
+	page1[21], page1[22], page1[23] = 64, 32, 32                 // payload fractions
+	binary.BigEndian.PutUint32(page1[24:], 1)                    // file change counter
+	binary.BigEndian.PutUint32(page1[28:], uint32(len(f.pages))) // database size in pages
+	binary.BigEndian.PutUint32(page1[40:], 1)                    // schema cookie
+	binary.BigEndian.PutUint32(page1[44:], 4)                    // schema format
+	binary.BigEndian.PutUint32(page1[56:], 1)                    // UTF-8
+	binary.BigEndian.PutUint32(page1[92:], 1)                    // version-valid-for, matching the change counter
+	binary.BigEndian.PutUint32(page1[96:], 3040001)              // SQLITE_VERSION_NUMBER of the format written
+
+	return bytes.Join(f.pages, nil), nil
+}
+
+// profileResult is one profile's outcome in a multi-profile run
+type profileResult struct {
+	Name    string
+	Res     interface{}
+	Kind    string
+	Raw     string
+	Err     error
+	Elapsed time.Duration
+}
+
+// snapshot is a result frozen under a name so it survives later queries
+type snapshot struct {
+	Name  string
+	Query string
+	Raw   string
+	Rows  []map[string]interface{} // the rows before column filters
+	Taken time.Time
+}
+
+// resultRowCount returns how many table rows a fetched result would produce
+func resultRowCount(res interface{}, kind string) int {
+	if kind != "json" {
+		return 0
+	}
+	switch v := res.(type) {
+	case []map[string]interface{}:
+		return len(v)
+	case []interface{}:
+		return len(normalizeRows(v))
+	}
+	return 0
+}
+
+// fetchAllProfiles runs a query against every configured profile concurrently
+func fetchAllProfiles(cfg *Config, query string, progress func(queued, running, done int)) []profileResult {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	results := make([]profileResult, len(names))
+	limit := cfg.MaxConcurrentRequests
+	if limit <= 0 {
+		limit = len(names)
+	}
+	// sem bounds the requests in flight; mu guards the counts reported to progress
+	sem := make(chan struct{}, max(limit, 1))
+	var mu sync.Mutex
+	queued, running, done := len(names), 0, 0
+	report := func(dQueued, dRunning, dDone int) {
+		mu.Lock()
+		queued, running, done = queued+dQueued, running+dRunning, done+dDone
+		q, r, d := queued, running, done
+		mu.Unlock()
+		if progress != nil {
+			progress(q, r, d)
+		}
+	}
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			report(-1, 1, 0)
+			start := time.Now()
+			res, kind, raw, err := fetchQuery(cfg, profileAPI(cfg, name), query)
+			results[i] = profileResult{Name: name, Res: res, Kind: kind, Raw: raw, Err: err, Elapsed: time.Since(start)}
+			report(0, -1, 1)
+		}(i, name)
+	}
+	wg.Wait()
+	return results
+}
+
+// cliOptions holds the parsed command-line arguments
+type cliOptions struct {
+	PrintURL    bool
+	Batch       string // .sql file to run statement by statement
+	StopOnError bool
+	Format      string
+	Query       string
+	Params      map[string]string // --param name=value, bound to :name placeholders
+	Demo        bool              // hidden --demo: query the built-in demo server
+}
+
+// parseArgs parses command-line flags; remaining arguments form the query
+func parseArgs(args []string) (cliOptions, error) {
+	opts := cliOptions{StopOnError: true, Format: "json"}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--print-url":
+			opts.PrintURL = true
+		case "--stop-on-error":
+			opts.StopOnError = true
+		case "--continue-on-error":
+			opts.StopOnError = false
+		case "--demo":
+			opts.Demo = true
+		case "--batch", "--format", "--param":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("%s requires a value", args[i])
+			}
+			switch args[i] {
+			case "--batch":
+				opts.Batch = args[i+1]
+			case "--format":
+				opts.Format = args[i+1]
+			case "--param":
+				name, value, ok := strings.Cut(args[i+1], "=")
+				if !ok || name == "" {
+					return opts, fmt.Errorf("--param expects name=value, got %q", args[i+1])
+				}
+				if opts.Params == nil {
+					opts.Params = make(map[string]string)
+				}
+				opts.Params[name] = value
+			}
+			i++
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	valid := false
+	for _, f := range exportFormats {
+		valid = valid || f == opts.Format
+	}
+	if !valid {
+		return opts, fmt.Errorf("unknown format %q (use %s)", opts.Format, strings.Join(exportFormats, ", "))
+	}
+	opts.Query = strings.Join(rest, " ")
+	return opts, nil
+}
+
+// printResult writes a fetched result to stdout in the given format
+func printResult(data interface{}, dataType, raw, format, query string) {
+	if dataType != "json" {
+		if dataType == "malformed" {
+			fmt.Fprintln(os.Stderr, "Warning: malformed JSON response")
+		}
+		fmt.Println(raw)
+		return
+	}
+	if format != "json" {
+		var rows []map[string]interface{}
+		switch v := data.(type) {
+		case []map[string]interface{}:
+			rows = v
+		case []interface{}:
+			rows = normalizeRows(v)
+		}
+		if len(rows) > 0 {
+			out, err := serializeRows(format, rows, dataColumns(rows), tableFromQuery(query, "results"))
+			if err == nil {
+				fmt.Print(out)
+				return
+			}
+		}
+	}
+	// Pretty print JSON
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		fmt.Println(raw)
+	} else {
+		fmt.Println(string(b))
+	}
+}
+
+// sqlTokenRe splits SQL into comments, quoted strings, words, numbers, whitespace and punctuation
+var sqlTokenRe = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/|'(?:[^']|'')*'|"(?:[^"]|"")*"|` + "`[^`]*`" +
+	`|[A-Za-z_][A-Za-z0-9_$]*|[0-9]+(?:\.[0-9]+)?|\s+|::|<>|!=|<=|>=|\|\||.`)
+
+// sqlKeywords are uppercased by formatSQL
+var sqlKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "AND": true, "OR": true, "NOT": true, "IN": true,
+	"IS": true, "NULL": true, "AS": true, "ON": true, "USING": true, "JOIN": true, "LEFT": true,
+	"RIGHT": true, "INNER": true, "OUTER": true, "FULL": true, "CROSS": true, "NATURAL": true,
+	"GROUP": true, "BY": true, "ORDER": true, "HAVING": true, "LIMIT": true, "OFFSET": true,
+	"UNION": true, "ALL": true, "DISTINCT": true, "INSERT": true, "INTO": true, "VALUES": true,
+	"UPDATE": true, "SET": true, "DELETE": true, "RETURNING": true, "WITH": true, "CASE": true,
+	"WHEN": true, "THEN": true, "ELSE": true, "END": true, "ASC": true, "DESC": true, "LIKE": true,
+	"ILIKE": true, "BETWEEN": true, "EXISTS": true, "TRUE": true, "FALSE": true,
+}
+
+// sqlClauses start a new line in formatSQL
+var sqlClauses = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "GROUP": true, "ORDER": true, "HAVING": true,
+	"LIMIT": true, "OFFSET": true, "UNION": true, "VALUES": true, "SET": true, "RETURNING": true,
+	"JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true, "FULL": true, "CROSS": true,
+	"NATURAL": true, "INSERT": true, "UPDATE": true, "DELETE": true, "WITH": true,
+}
+
+// joinModifiers may precede JOIN on the same line
+var joinModifiers = map[string]bool{"LEFT": true, "RIGHT": true, "INNER": true, "OUTER": true, "FULL": true, "CROSS": true, "NATURAL": true}
+
+// formatSQL is a small rule-based formatter: it uppercases keywords, starts each top-level
+// clause on its own line, lists selected columns one per line and indents AND/OR conditions.
+// Nested queries in parentheses are kept on one line.
+func formatSQL(sql string) string {
+	var out []byte
+	depth := 0
+	clause := ""         // current top-level clause
+	prev, last := "", "" // previous word (uppercased) and previous token
+	listStart := false   // the SELECT list starts on the next line
+	between := false     // the next AND belongs to BETWEEN
+	breakNext := false   // a line comment must end its line
+
+	newline := func(indent string) {
+		for len(out) > 0 && out[len(out)-1] == ' ' {
+			out = out[:len(out)-1]
+		}
+		if len(out) > 0 && out[len(out)-1] != '\n' {
+			out = append(out, '\n')
+		}
+		out = append(out, indent...)
+	}
+	emit := func(t string) {
+		noSpace := len(out) == 0 || out[len(out)-1] == '\n' || out[len(out)-1] == ' ' ||
+			t == "," || t == ")" || t == "." || t == ";" || t == "::" ||
+			last == "(" || last == "." || last == "::" ||
+			(t == "(" && prev == strings.ToUpper(last) && !sqlKeywords[prev] && clause != "INSERT") // function call
+		if !noSpace {
+			out = append(out, ' ')
+		}
+		out = append(out, t...)
+		last = t
+	}
+
+	for _, t := range sqlTokenRe.FindAllString(sql, -1) {
+		if strings.TrimSpace(t) == "" {
+			continue
+		}
+		if breakNext {
+			newline("")
+			breakNext = false
+		}
+		u := strings.ToUpper(t)
+		isWord := unicode.IsLetter(rune(t[0])) || t[0] == '_'
+		if isWord && sqlKeywords[u] {
+			t = u
+		}
+		top := depth == 0
+		switch {
+		case strings.HasPrefix(t, "--"):
+			emit(t)
+			breakNext = true
+		case isWord && top && sqlClauses[u] && !(joinModifiers[prev] && (u == "JOIN" || joinModifiers[u])) && !(u == "FROM" && prev == "DELETE"):
+			newline("")
+			emit(t)
+			clause = u
+			if joinModifiers[u] {
+				clause = "JOIN"
+			}
+			listStart = u == "SELECT"
+		case isWord && top && (u == "AND" || u == "OR") && !between:
+			newline("  ")
+			emit(t)
+		case t == "," && top && clause == "SELECT":
+			emit(t)
+			newline("  ")
+		default:
+			if listStart && u != "DISTINCT" && u != "ALL" {
+				newline("  ")
+				listStart = false
+			}
+			if t == ")" && depth > 0 {
+				depth--
+			}
+			emit(t)
+			if t == "(" {
+				depth++
+			}
+		}
+		if u == "BETWEEN" {
+			between = true
+		} else if u == "AND" {
+			between = false
+		}
+		if isWord {
+			prev = u
+		} else {
+			prev = ""
+		}
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// limitOffsetRe matches a trailing LIMIT n [OFFSET m] clause
+var limitOffsetRe = regexp.MustCompile(`(?is)\s+limit\s+(\d+)(?:\s+offset\s+(\d+))?[\s;]*$`)
+
+// queryWindow returns a query's trailing LIMIT and OFFSET, if it has a LIMIT clause
+func queryWindow(query string) (limit, offset int, ok bool) {
+	m := limitOffsetRe.FindStringSubmatch(query)
+	if m == nil {
+		return 0, 0, false
+	}
+	limit, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		offset, _ = strconv.Atoi(m[2])
+	}
+	return limit, offset, true
+}
+
+// setQueryWindow replaces a query's trailing LIMIT/OFFSET clause, or appends one
+func setQueryWindow(query string, limit, offset int) string {
+	query = stripTrailingSemicolons(strings.TrimSpace(query))
+	query = limitOffsetRe.ReplaceAllString(query, "")
+	query += fmt.Sprintf(" LIMIT %d", limit)
+	if offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", offset)
+	}
+	return query
+}
+
+// numberLiteralRe matches parameter values that can be bound as bare numbers
+var numberLiteralRe = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// bindParams replaces :name placeholders outside quoted strings with the escaped param
+// values: numbers as-is (negative ones in parentheses, so x-:n never becomes a -- comment),
+// anything else as a quoted SQL string. Casts like ::text are left alone, and a placeholder
+// without a param is an error.
+func bindParams(query string, params map[string]string) (string, error) {
+	tokens := sqlTokenRe.FindAllString(query, -1)
+	var b strings.Builder
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t == ":" && i+1 < len(tokens) && (unicode.IsLetter(rune(tokens[i+1][0])) || tokens[i+1][0] == '_') {
+			name := tokens[i+1]
+			value, ok := params[name]
+			if !ok {
+				return "", fmt.Errorf("no --param given for :%s", name)
+			}
+			switch {
+			case !numberLiteralRe.MatchString(value):
+				b.WriteString(sqlLiteral(value))
+			case strings.HasPrefix(value, "-"):
+				b.WriteString("(" + value + ")")
+			default:
+				b.WriteString(value)
+			}
+			i++
+			continue
+		}
+		b.WriteString(t)
+	}
+	return b.String(), nil
+}
+
+// stripTrailingSemicolons removes trailing semicolons and whitespace, unless the query
+// ends inside an unterminated quoted string
+func stripTrailingSemicolons(query string) string {
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		}
+	}
+	if quote != 0 {
+		return query
+	}
+	return strings.TrimRight(query, " \t\r\n;")
+}
+
+// stripComments removes -- line and /* */ block comments outside quoted strings. Only the
+// whitespace between tokens is tidied, so lines left empty are dropped but quoted strings
+// are sent unchanged.
+func stripComments(query string) string {
+	var b strings.Builder
+	var ws string
+	changed := false
+	for _, tok := range sqlTokenRe.FindAllString(query, -1) {
+		switch {
+		case strings.HasPrefix(tok, "--"):
+			changed = true
+		case strings.HasPrefix(tok, "/*"):
+			// keep the words on either side apart
+			ws += " "
+			changed = true
+		case strings.TrimSpace(tok) == "":
+			ws += tok
+		default:
+			if b.Len() > 0 {
+				b.WriteString(collapseBlankLines(ws))
+			}
+			ws = ""
+			b.WriteString(tok)
+		}
+	}
+	if !changed {
+		return query
+	}
+	return b.String()
+}
+
+// collapseBlankLines reduces a run of whitespace spanning several lines to a single line
+// break followed by the indentation of the last line
+func collapseBlankLines(ws string) string {
+	i := strings.LastIndex(ws, "\n")
+	if i < 0 {
+		return ws
+	}
+	return "\n" + ws[i+1:]
+}
+
+// aliasArgRe matches $1..$9 argument placeholders in alias SQL
+var aliasArgRe = regexp.MustCompile(`\$([1-9])`)
+
+// expandAlias expands "@name args..." to the SQL stored under name in Aliases. Arguments
+// fill $1..$9 placeholders; without placeholders they are appended to the SQL.
+func expandAlias(cfg *Config, query string) (string, error) {
+	query = strings.TrimSpace(query)
+	if !strings.HasPrefix(query, "@") {
+		return query, nil
+	}
+	name, rest, _ := strings.Cut(query[1:], " ")
+	name = strings.TrimSpace(name)
+	sql, ok := cfg.Aliases[name]
+	if !ok {
+		return "", fmt.Errorf("unknown alias @%s", name)
+	}
+	rest = strings.TrimSpace(rest)
+	if !aliasArgRe.MatchString(sql) {
+		if rest != "" {
+			sql += " " + rest
+		}
+		return sql, nil
+	}
+	args := strings.Fields(rest)
+	var missing string
+	sql = aliasArgRe.ReplaceAllStringFunc(sql, func(m string) string {
+		i := int(m[1] - '1')
+		if i >= len(args) {
+			missing = m
+			return m
+		}
+		return args[i]
+	})
+	if missing != "" {
+		return "", fmt.Errorf("alias @%s needs an argument for %s", name, missing)
+	}
+	return sql, nil
+}
+
+// prepareQuery applies the configured rewrites to a query just before it is sent
+func prepareQuery(cfg *Config, query string) string {
+	if cfg.StripComments {
+		query = stripComments(query)
+	}
+	query = strings.TrimSpace(query)
+	if cfg.StripTrailingSemicolon {
+		query = stripTrailingSemicolons(query)
+	}
+	return query
+}
+
+// splitStatements splits SQL on semicolons outside quotes and comments
+func splitStatements(sql string) []string {
+	var stmts []string
+	var cur strings.Builder
+	flush := func() {
+		if st := strings.TrimSpace(cur.String()); st != "" {
+			stmts = append(stmts, st)
+		}
+		cur.Reset()
+	}
+	var quote rune // active quote character, if any
+	lineComment, blockComment := false, false
+	rs := []rune(sql)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		next := rune(0)
+		if i+1 < len(rs) {
+			next = rs[i+1]
+		}
+		switch {
+		case lineComment:
+			if r == '\n' {
+				lineComment = false
+			}
+		case blockComment:
+			if r == '*' && next == '/' {
+				blockComment = false
+				cur.WriteRune(r)
+				r = next
+				i++
+			}
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '-' && next == '-':
+			lineComment = true
+		case r == '/' && next == '*':
+			blockComment = true
+		case r == ';':
+			flush()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	flush()
+	return stmts
+}
+
+// stdoutPiped reports whether stdout is redirected to a file or pipe rather than a terminal
+func stdoutPiped() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice == 0
+}
+
+// runBatch runs the statements of opts.Batch in order and returns the process exit code.
+// When stdout is piped only the results are written to it: JSON output becomes one array
+// holding each statement's result, with {"error": ...} in place of a failed statement's result
+// so positions still match the statements; other formats are separated by a blank line.
+// Statements are prepared like single queries, and one left empty by strip_comments is
+// skipped (null in the JSON array).
+func runBatch(cfg *Config, base string, opts cliOptions) int {
+	b, err := os.ReadFile(opts.Batch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	stmts := splitStatements(string(b))
+	piped := stdoutPiped()
+	jsonOut := opts.Format == "json"
+	var results []interface{}
+	succeeded, failed := 0, 0
+	// every statement sent goes to the query log, like a single query
+	logQuery := func(sql string, start time.Time, res interface{}, kind string, err error) {
+		if logErr := appendQueryLog(cfg, newQueryLogEntry(cfg, sql, start, res, kind, false, err)); logErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write query log: %v\n", logErr)
+		}
+	}
+	for i, stmt := range stmts {
+		if !piped {
+			fmt.Printf("-- [%d/%d] %s\n", i+1, len(stmts), stmt)
+		}
+		sql, err := expandAlias(cfg, stmt)
+		if err == nil && len(opts.Params) > 0 {
+			sql, err = bindParams(sql, opts.Params)
+		}
+		if err == nil {
+			sql = prepareQuery(cfg, sql)
+		}
+		if err == nil && sql == "" {
+			// nothing but comments, removed by strip_comments; null keeps the JSON positions
+			if piped && jsonOut {
+				results = append(results, nil)
+			}
+			continue
+		}
+		var body []byte
+		var code int
+		start, sent := time.Now(), err == nil
+		if sent {
+			body, code, err = fetchRaw(cfg, base, sql)
+		}
+		if err == nil && code >= 400 {
+			err = fmt.Errorf("HTTP %d: %s", code, strings.TrimSpace(string(body)))
+		}
+		if err != nil {
+			if sent {
+				logQuery(sql, start, nil, "", err)
+			}
+			failed++
+			fmt.Fprintf(os.Stderr, "Error in statement %d: %v\n", i+1, err)
+			if piped && jsonOut {
+				results = append(results, map[string]string{"error": err.Error()})
+			}
+			if opts.StopOnError {
+				break
+			}
+			continue
+		}
+		succeeded++
+		data, dataType, raw := parseResponse(body)
+		logQuery(sql, start, data, dataType, nil)
+		switch {
+		case !piped:
+			printResult(data, dataType, raw, opts.Format, stmt)
+		case jsonOut && dataType == "json":
+			results = append(results, data)
+		case jsonOut:
+			results = append(results, raw)
+		default:
+			if succeeded > 1 {
+				fmt.Println()
+			}
+			printResult(data, dataType, raw, opts.Format, stmt)
+		}
+	}
+	if piped && jsonOut {
+		if results == nil {
+			results = []interface{}{}
+		}
+		out, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(out))
+	}
+	if !piped {
+		skipped := len(stmts) - succeeded - failed
+		fmt.Fprintf(os.Stderr, "Batch: %d succeeded, %d failed, %d skipped\n", succeeded, failed, skipped)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// demoTables holds the canned data served by --demo
+var demoTables = map[string][]map[string]interface{}{
+	"Patients": {
+		{"id": 1, "firstName": "Ada", "lastName": "Lovelace", "email": "ada@example.com", "active": true, "createdAt": "2024-01-15T09:30:00Z"},
+		{"id": 2, "firstName": "Alan", "lastName": "Turing", "email": "alan@example.com", "active": true, "createdAt": "2024-02-03T14:05:00Z"},
+		{"id": 3, "firstName": "Grace", "lastName": "Hopper", "email": "grace@example.com", "active": false, "createdAt": "2024-02-20T11:45:00Z"},
+		{"id": 4, "firstName": "Edsger", "lastName": "Dijkstra", "email": nil, "active": true, "createdAt": "2024-03-08T16:20:00Z"},
+		{"id": 5, "firstName": "Barbara", "lastName": "Liskov", "email": "barbara@example.com", "active": true, "createdAt": "2024-04-12T08:10:00Z"},
+	},
+	"Appointments": {
+		{"id": 101, "patientId": 1, "startsAt": "2024-05-01T09:00:00Z", "status": "completed", "notes": "Annual checkup"},
+		{"id": 102, "patientId": 2, "startsAt": "2024-05-01T10:30:00Z", "status": "cancelled", "notes": nil},
+		{"id": 103, "patientId": 1, "startsAt": "2024-06-11T13:15:00Z", "status": "booked", "notes": "Follow-up"},
+		{"id": 104, "patientId": 5, "startsAt": "2024-06-12T15:00:00Z", "status": "booked", "notes": nil},
+	},
+}
+
+// demoCountRe matches queries that only count rows
+var demoCountRe = regexp.MustCompile(`(?is)^\s*select\s+count\(\*\)\s+from\b`)
+
+// demoHandler answers queries from demoTables: count(*), or the table's rows with LIMIT/OFFSET
+// applied. Everything else in the query is ignored.
+func demoHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.FormValue("q")
+	if query == "" && strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body struct {
+			Q string `json:"q"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		query = body.Q
+	}
+	if strings.TrimSpace(query) == "" {
+		// connection checks request the endpoint without a query
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	table := strings.Trim(tableFromQuery(query, ""), `"`)
+	var rows []map[string]interface{}
+	found := false
+	names := make([]string, 0, len(demoTables))
+	for name, data := range demoTables {
+		names = append(names, name)
+		if strings.EqualFold(name, table) {
+			rows, found = data, true
+		}
+	}
+	if !found {
+		sort.Strings(names)
+		http.Error(w, fmt.Sprintf("demo: unknown table %q (try %s)", table, strings.Join(names, ", ")), http.StatusBadRequest)
+		return
+	}
+	var out interface{} = rows
+	if demoCountRe.MatchString(query) {
+		out = []map[string]interface{}{{"count": len(rows)}}
+	} else if m := limitOffsetRe.FindStringSubmatch(query); m != nil {
+		limit, _ := strconv.Atoi(m[1])
+		offset, _ := strconv.Atoi(m[2])
+		offset = min(offset, len(rows))
+		out = rows[offset:min(offset+limit, len(rows))]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// startDemoServer serves demoHandler on a free local port and returns its API base
+func startDemoServer() (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	go http.Serve(ln, http.HandlerFunc(demoHandler))
+	return "http://" + ln.Addr().String() + "/db?q=", nil
+}
+
+func main() {
+	// Load configuration
+	cfg, err := loadConfig()
+	if err != nil {
+		// only a reload keeps the previous config; at startup the defaults are all there is
+		fmt.Fprintf(os.Stderr, "Warning: Failed to load config, using defaults: %v\n", err)
+		defCfg := DefaultConfig()
+		cfg = &defCfg
+	}
+
+	opts, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Hidden --demo: answer queries from canned data on an in-process server, so dbx can be
+	// tried and tested without the real API. The config file is left untouched.
+	if opts.Demo {
+		base, err := startDemoServer()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to start demo server: %v\n", err)
+			os.Exit(1)
+		}
+		apiOverride = base
+		os.Args = slices.DeleteFunc(os.Args, func(a string) bool { return a == "--demo" })
+	}
+
+	// --open FILE: browse exported results in the TUI without a backend; running is disabled
+	var replayPath, replayRaw string
+	var replayRows []map[string]interface{}
+	if len(os.Args) > 1 && os.Args[1] == "--open" {
+		if len(os.Args) != 3 {
+			fmt.Fprintln(os.Stderr, "Error: --open expects one JSON file")
+			os.Exit(1)
+		}
+		b, err := os.ReadFile(os.Args[2])
+		if err == nil {
+			err = decodeJSON(b, &replayRows)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s is not a JSON array of rows: %v\n", os.Args[2], err)
+			os.Exit(1)
+		}
+		replayPath, replayRaw = os.Args[2], string(b)
+		os.Args = os.Args[:1]
+	}
+
+	// Check for command-line query argument
+	if len(os.Args) > 1 {
+		// Show usage hint if no valid query detected
+		if len(os.Args) == 2 && (os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help") {
+			fmt.Println("Usage:")
+			fmt.Println("  dbx                    Start interactive TUI")
+			fmt.Println("  dbx 'QUERY'            Execute query and output JSON")
+			fmt.Println("  dbx --print-url 'QUERY'  Print the request URL without running it")
+			fmt.Println("  dbx --batch FILE.sql   Run each ;-separated statement in FILE.sql")
+			fmt.Println("  dbx @ALIAS [ARGS...]   Run a query alias from the config")
+			fmt.Println("  dbx --open FILE.json   Browse exported results offline (running queries is disabled)")
+			fmt.Println("")
+			fmt.Println("Options:")
+			fmt.Println("  --param NAME=VALUE     Bind VALUE to :NAME placeholders (repeatable)")
+			fmt.Println("  --format FORMAT        Output format: json (default), csv, tsv, markdown, insert")
+			fmt.Println("  --stop-on-error        Stop a batch at the first failing statement (default)")
+			fmt.Println("  --continue-on-error    Run the remaining statements after a failure")
+			fmt.Println("")
+			fmt.Println("Examples:")
+			fmt.Println("  dbx 'select * from Patients limit 1'")
+			fmt.Println("  dbx 'select count(*) from Users'")
+			fmt.Println("")
+			fmt.Println("Note: Quote the entire query to prevent shell expansion of * and other special characters")
+			return
+		}
+
+		base := profileAPI(cfg, cfg.Profile)
+
+		// Batch: run each statement of a file in order
+		if opts.Batch != "" {
+			os.Exit(runBatch(cfg, base, opts))
+		}
+
+		query, err := expandAlias(cfg, opts.Query)
+		if err == nil && len(opts.Params) > 0 {
+			query, err = bindParams(query, opts.Params)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		query = prepareQuery(cfg, query)
+		if query == "" {
+			fmt.Fprintln(os.Stderr, "Error: no query given")
+			os.Exit(1)
+		}
+
+		// Dry run: show the URL that would be requested and exit
+		if opts.PrintURL {
+			base := routeAPI(cfg, base, query)
+			if strings.EqualFold(cfg.Method, "POST") {
+				body, contentType := postBody(cfg, query)
+				fmt.Printf("POST %s\nContent-Type: %s\n\n%s\n", withParams(cfg, endpointURL(base)), contentType, body)
+				return
+			}
+			fmt.Println(withParams(cfg, queryURL(base, query)))
+			return
+		}
+
+		start := time.Now()
+		data, dataType, raw, err := fetchQuery(cfg, base, query)
+		if logErr := appendQueryLog(cfg, newQueryLogEntry(cfg, query, start, data, dataType, false, err)); logErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write query log: %v\n", logErr)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printResult(data, dataType, raw, opts.Format, query)
+		return
+	}
+
+	// No arguments - start TUI
+	app := tview.NewApplication()
+	apiBase := profileAPI(cfg, cfg.Profile)
+
+	// UI components
+	historyList := tview.NewList().ShowSecondaryText(false)
 	historyList.SetBorder(true).SetTitle("History")
 	
-	// Declare updateFocusColors early so we can use it in mouse handlers
-	var updateFocusColors func(tview.Primitive)
+	// Declare updateFocusColors early so we can use it in mouse handlers
+	var updateFocusColors func(tview.Primitive)
+	// The zoomed pane (nil when none) fills the screen; it follows focus so Tab and Alt-1..5 stay usable
+	var zoomed tview.Primitive
+	var applyLayout func()
+
+	historyPreview := tview.NewTextView().SetDynamicColors(true).SetScrollable(true).SetWordWrap(true)
+	historyPreview.SetBorder(true).SetTitle("Preview")
+
+	queryInput := tview.NewTextView()
+	queryInput.SetBorder(true).SetTitle("Query (Press Ctrl-R to run, Ctrl-S to save to history)")
+	queryInput.SetDynamicColors(true).SetRegions(true).SetWordWrap(true)
+	// use an input capture on the page to accept typed text into the query area
+
+	// In ctrl-enter-runs mode Enter inserts a newline and Ctrl-Enter runs
+	ctrlEnterRuns := cfg.EnterRuns == "ctrl-enter-runs"
+	runKey := "Enter"
+	if ctrlEnterRuns {
+		runKey = "Ctrl-Enter"
+	}
+
+	editor := tview.NewTextArea()
+	editor.SetPlaceholder("Enter SQL, press " + runKey + " to run")
+	editor.SetBorder(true).SetTitle("Editor")
+
+	resultsTable := tview.NewTable().SetFixed(1, cfg.PinnedColumns).SetSelectable(true, true)
+	resultsTable.SetBorder(true).SetTitle("Results")
+
+	// cardsView shows the results as one field: value card per row, in place of the table. The
+	// table keeps the focus and selection; the card of the selected row is highlighted.
+	cardsView := tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetScrollable(true).SetWrap(false)
+	cardsView.SetBorder(true).SetTitle("Results")
+	cardMode := cfg.DefaultView == "cards"
+	
+	// Tracks key repeat for faster scrolling
+	var tableRepeat keyRepeat
+	
+	// Add faster scrolling for results table with acceleration
+	resultsTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, col := resultsTable.GetSelection()
+		lastRow := lastSelectableRow(resultsTable)
+		
+		switch event.Key() {
+		case tcell.KeyPgDn:
+			// Jump down by configured page step
+			newRow := row + cfg.PageScrollStep
+			if newRow > lastRow {
+				newRow = lastRow
+			}
+			resultsTable.Select(newRow, col)
+			return nil
+		case tcell.KeyPgUp:
+			// Jump up by configured page step
+			newRow := row - cfg.PageScrollStep
+			if newRow < 1 { // Skip header row (row 0)
+				newRow = 1
+			}
+			resultsTable.Select(newRow, col)
+			return nil
+		case tcell.KeyDown, tcell.KeyUp:
+			// Calculate scroll step: start with 1, accelerate after threshold
+			step := tableRepeat.step(event.Key(), time.Now(), cfg)
+			
+			var newRow int
+			if event.Key() == tcell.KeyDown {
+				newRow = row + step
+				if newRow > lastRow {
+					newRow = lastRow
+				}
+			} else {
+				newRow = row - step
+				if newRow < 1 {
+					newRow = 1
+				}
+			}
+			resultsTable.Select(newRow, col)
+			return nil
+		}
+		return event
+	})
+
+	detailView := tview.NewTextView().SetDynamicColors(true).SetScrollable(true).SetWordWrap(true)
+	detailView.SetBorder(true).SetTitle("Detail")
+
+	// detailGrid shows the selected row as a field | value table in place of detailView when
+	// detail_table is on. detailView keeps the focus and passes its scrolling keys on.
+	detailGrid := tview.NewTable().SetFixed(1, 1)
+	detailGrid.SetBorder(true).SetTitle("Detail")
+	detailSlot := tview.NewPages().
+		AddPage("text", detailView, true, true).
+		AddPage("table", detailGrid, true, false)
+
+	rawView := tview.NewTextView().SetDynamicColors(true).SetScrollable(true)
+	rawView.SetBorder(true).SetTitle("Raw Output")
+
+	// The detail and raw views scroll with the same acceleration as the results table
+	acceleratedScroll := func(v *tview.TextView) func(*tcell.EventKey) *tcell.EventKey {
+		var repeat keyRepeat
+		return func(event *tcell.EventKey) *tcell.EventKey {
+			if event.Key() != tcell.KeyDown && event.Key() != tcell.KeyUp {
+				return event
+			}
+			step := repeat.step(event.Key(), time.Now(), cfg)
+			row, col := v.GetScrollOffset()
+			if event.Key() == tcell.KeyUp {
+				step = -step
+			}
+			v.ScrollTo(max(row+step, 0), col)
+			return nil
+		}
+	}
+	detailScroll := acceleratedScroll(detailView)
+	detailView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if name, _ := detailSlot.GetFrontPage(); name == "table" {
+			switch event.Key() {
+			case tcell.KeyUp, tcell.KeyDown, tcell.KeyLeft, tcell.KeyRight, tcell.KeyPgUp, tcell.KeyPgDn, tcell.KeyHome, tcell.KeyEnd:
+				detailGrid.InputHandler()(event, func(tview.Primitive) {})
+				return nil
+			}
+			return event
+		}
+		return detailScroll(event)
+	})
+	rawView.SetInputCapture(acceleratedScroll(rawView))
+
+	connectionStatus := tview.NewTextView().SetDynamicColors(true)
+	connectionStatus.SetBorder(true).SetTitle("Connection")
+	connectionStatus.SetText("[yellow]●[white] Checking...")
+
+	// Read-only line showing the query that produced the displayed results
+	resultQueryView := tview.NewTextView().SetDynamicColors(true).SetWrap(false)
+	resultQueryView.SetBorder(true).SetTitle("Showing results of")
+	resultQueryView.SetText("[gray]No query run yet")
+
+	// Paging toolbar: the LIMIT/OFFSET window of the displayed query
+	windowView := tview.NewTextView().SetDynamicColors(true)
+	windowView.SetBorder(true).SetTitle("Page [ ] { }")
+	windowView.SetText("[gray]no LIMIT")
+
+	// Recent errors counter, shown once a query fails
+	errorsView := tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignCenter)
+	errorsView.SetBorder(true).SetTitle("F7")
+
+	// Production warning, shown when the API host is listed in dangerous_hosts
+	dangerBanner := tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignCenter)
+	dangerBanner.SetBorder(true).SetBorderColor(tcell.ColorRed)
+	dangerBanner.SetText("[red::b]⚠ PRODUCTION")
+
+	status := tview.NewTextView().SetDynamicColors(true)
+	status.SetBorder(false)
+	
+	// Function to update border colors based on focus
+	updateFocusColors = func(focused tview.Primitive) {
+		// Reset all borders to default color
+		historyList.SetBorderColor(tcell.ColorWhite)
+		editor.SetBorderColor(tcell.ColorWhite)
+		resultsTable.SetBorderColor(tcell.ColorWhite)
+		cardsView.SetBorderColor(tcell.ColorWhite)
+		detailView.SetBorderColor(tcell.ColorWhite)
+		detailGrid.SetBorderColor(tcell.ColorWhite)
+		rawView.SetBorderColor(tcell.ColorWhite)
+		
+		// Highlight focused pane with green
+		switch focused {
+		case historyList:
+			historyList.SetBorderColor(tcell.ColorGreen)
+		case editor:
+			editor.SetBorderColor(tcell.ColorGreen)
+		case resultsTable:
+			resultsTable.SetBorderColor(tcell.ColorGreen)
+			cardsView.SetBorderColor(tcell.ColorGreen)
+		case detailView:
+			detailView.SetBorderColor(tcell.ColorGreen)
+			detailGrid.SetBorderColor(tcell.ColorGreen)
+		case rawView:
+			rawView.SetBorderColor(tcell.ColorGreen)
+		default:
+			return
+		}
+		if zoomed != nil && zoomed != focused {
+			zoomed = focused
+			applyLayout()
+		}
+	}
+	
+	// Pages let modals float above the main layout
+	pages := tview.NewPages()
+
+	// Add mouse handlers to update focus colors on click, or on hover with focus-follows-mouse
+	mouseFocus := func(p tview.Primitive) func(tview.MouseAction, *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		return func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+			// While a modal is open the panes behind it ignore the mouse
+			if pages.GetPageCount() > 1 {
+				return action, nil
+			}
+			switch {
+			case action == tview.MouseLeftClick:
+				updateFocusColors(p)
+			case action == tview.MouseMove && cfg.FocusFollowsMouse && event.Buttons() == tcell.ButtonNone && app.GetFocus() != p:
+				// only plain hovering switches panes, so drags keep their target
+				app.SetFocus(p)
+				updateFocusColors(p)
+			}
+			return action, event
+		}
+	}
+	historyList.SetMouseCapture(mouseFocus(historyList))
+	editor.SetMouseCapture(mouseFocus(editor))
+	resultsTable.SetMouseCapture(mouseFocus(resultsTable))
+	detailView.SetMouseCapture(mouseFocus(detailView))
+	rawView.SetMouseCapture(mouseFocus(rawView))
+	// clicking a card focuses the results, whose keys drive the cards; the wheel still scrolls
+	cardsView.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		action, event = mouseFocus(resultsTable)(action, event)
+		if event != nil && (action == tview.MouseLeftDown || action == tview.MouseLeftClick) {
+			app.SetFocus(resultsTable)
+			return action, nil
+		}
+		return action, event
+	})
+	// the detail table stands in for the detail view, which takes the focus
+	detailGrid.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		action, event = mouseFocus(detailView)(action, event)
+		if event != nil && (action == tview.MouseLeftDown || action == tview.MouseLeftClick) {
+			app.SetFocus(detailView)
+			return action, nil
+		}
+		return action, event
+	})
+
+	// layout
+	flex := tview.NewFlex().SetDirection(tview.FlexRow)
+	
+	// Top bar with connection status
+	topBar := tview.NewFlex()
+	topBar.AddItem(connectionStatus, 20, 0, false)
+	topBar.AddItem(resultQueryView, 0, 1, false)
+	topBar.AddItem(windowView, 26, 0, false)
+	topBar.AddItem(errorsView, 0, 0, false)
+	if isDangerousHost(cfg, apiBase) {
+		topBar.AddItem(dangerBanner, 16, 0, false)
+	}
+	
+	flex.AddItem(topBar, 3, 0, false)
+	
+	top := tview.NewFlex()
+	
+	// History column with list and preview
+	historyColumn := tview.NewFlex().SetDirection(tview.FlexRow)
+	historyColumn.AddItem(historyList, 0, 2, false)
+	historyColumn.AddItem(historyPreview, 0, 1, false)
+	
+	top.AddItem(historyColumn, cfg.Layout.HistoryWidth, 1, false)
+
+	bottomRow := tview.NewFlex()
+	bottomRow.AddItem(detailSlot, 0, cfg.Layout.DetailWeight, true)
+	bottomRow.AddItem(rawView, 0, cfg.Layout.RawWeight, true)
+
+	center := tview.NewFlex().SetDirection(tview.FlexRow)
+	center.AddItem(editor, cfg.Layout.EditorHeight, 0, true)
+	resultsSlot := tview.NewPages().
+		AddPage("table", resultsTable, true, true).
+		AddPage("cards", cardsView, true, false)
+	center.AddItem(resultsSlot, 0, cfg.Layout.ResultsWeight, false)
+	center.AddItem(bottomRow, 0, cfg.Layout.BottomWeight, true)
+
+	top.AddItem(center, 0, 3, true)
+
+	flex.AddItem(top, 0, 1, true)
+	flex.AddItem(status, 1, 0, false)
+
+	// applyLayout resizes the panes to the configured proportions, or gives all the room to the zoomed pane
+	applyLayout = func() {
+		if zoomed != nil {
+			size := func(p ...tview.Primitive) int {
+				for _, q := range p {
+					if q == zoomed {
+						return 1
+					}
+				}
+				return 0
+			}
+			top.ResizeItem(historyColumn, 0, size(historyList))
+			top.ResizeItem(center, 0, 1-size(historyList))
+			historyColumn.ResizeItem(historyPreview, 0, 0)
+			center.ResizeItem(editor, 0, size(editor))
+			center.ResizeItem(resultsSlot, 0, size(resultsTable))
+			center.ResizeItem(bottomRow, 0, size(detailView, rawView))
+			bottomRow.ResizeItem(detailSlot, 0, size(detailView))
+			bottomRow.ResizeItem(rawView, 0, size(rawView))
+			return
+		}
+		l := cfg.Layout
+		top.ResizeItem(historyColumn, l.HistoryWidth, 1)
+		top.ResizeItem(center, 0, 3)
+		previewWeight := l.PreviewWeight
+		if l.HidePreview {
+			previewWeight = 0
+		}
+		historyColumn.ResizeItem(historyList, 0, l.HistoryListWeight)
+		historyColumn.ResizeItem(historyPreview, 0, previewWeight)
+		center.ResizeItem(editor, l.EditorHeight, 0)
+		center.ResizeItem(resultsSlot, 0, l.ResultsWeight)
+		center.ResizeItem(bottomRow, 0, l.BottomWeight)
+		detailWeight, rawWeight := l.DetailWeight, l.RawWeight
+		switch l.Collapsed {
+		case "detail":
+			detailWeight = 0
+		case "raw":
+			rawWeight = 0
+		}
+		bottomRow.ResizeItem(detailSlot, 0, detailWeight)
+		bottomRow.ResizeItem(rawView, 0, rawWeight)
+	}
+	applyLayout()
+
+	// history loading
+	hist, err := loadHistory()
+	if err != nil {
+		// ignore errors but show in status
+		status.SetText(fmt.Sprintf("[red]Failed to load history: %v", err))
+		hist = &History{Entries: []HistoryEntry{}}
+	}
+	// saved is what history.json holds. With auto_save_history off, queries only enter the
+	// session's history and saved changes just when entries are saved or deleted explicitly.
+	saved := hist
+	if !cfg.AutoSaveHistory {
+		saved = &History{Entries: slices.Clone(hist.Entries)}
+	}
+
+	// examplesText lists the example queries shown while history is empty
+	examplesText := func() string {
+		if len(cfg.ExampleQueries) == 0 {
+			return "[gray]No history available"
+		}
+		var b strings.Builder
+		b.WriteString("[gray]No history yet. Some queries to try:[white]\n")
+		for _, q := range cfg.ExampleQueries {
+			b.WriteString("\n" + tview.Escape(q) + "\n")
+		}
+		return b.String()
+	}
+
+	// historyIndex maps history list positions to entries; date headers map to -1
+	var historyIndex []int
+	entryAt := func(pos int) int {
+		if pos < 0 || pos >= len(historyIndex) {
+			return -1
+		}
+		return historyIndex[pos]
+	}
+	lastHistoryPos := 0
+
+	// historyShown reports whether an entry belongs in the history list: the active profile's
+	// queries, or every profile's with history_all_profiles
+	historyShown := func(e HistoryEntry) bool {
+		return cfg.HistoryAllProfiles || e.Profile == cfg.Profile
+	}
+
+	// helper to set status message
+	setStatus := func(format string, a ...interface{}) {
+		status.SetText(fmt.Sprintf(format, a...))
+	}
+
+	// showModal displays p centered over the main layout and focuses the given primitive
+	showModal := func(name string, p, focus tview.Primitive, width, height int) {
+		centered := tview.NewFlex().
+			AddItem(nil, 0, 1, false).
+			AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+				AddItem(nil, 0, 1, false).
+				AddItem(p, height, 1, true).
+				AddItem(nil, 0, 1, false), width, 1, true).
+			AddItem(nil, 0, 1, false)
+		pages.AddPage(name, centered, true, true)
+		app.SetFocus(focus)
+	}
+
+	// closeModal removes a modal and returns focus to the pane that opened it
+	closeModal := func(name string, back tview.Primitive) {
+		pages.RemovePage(name)
+		app.SetFocus(back)
+		updateFocusColors(back)
+	}
+
+	// loadFromHistory puts a history query in the editor, then calls done. With
+	// confirm_editor_overwrite, a query being typed that isn't in history is only replaced
+	// after the user agrees.
+	loadFromHistory := func(query string, done func()) {
+		text := strings.TrimSpace(editor.GetText())
+		unsaved := text != "" && text != query && !slices.ContainsFunc(hist.Entries, func(e HistoryEntry) bool { return e.Query == text })
+		load := func() {
+			editor.SetText(query, true)
+			done()
+		}
+		if !cfg.ConfirmEditorOverwrite || !unsaved {
+			load()
+			return
+		}
+		back := app.GetFocus()
+		confirm := tview.NewModal().
+			SetText("The editor has a query that isn't in history.\n\nReplace it?").
+			AddButtons([]string{"Keep", "Replace"}).
+			SetDoneFunc(func(_ int, label string) {
+				closeModal("overwrite-editor", back)
+				if label == "Replace" {
+					load()
+				} else {
+					setStatus("[yellow]Kept the editor query (Ctrl-S saves it to history)")
+				}
+			})
+		showModal("overwrite-editor", confirm, confirm, 60, 9)
+	}
+
+	refreshHistoryList := func() {
+		// Nudge new users with an example until they have history of their own
+		if len(hist.Entries) == 0 && len(cfg.ExampleQueries) > 0 {
+			editor.SetPlaceholder("Enter SQL, press " + runKey + " to run (e.g. " + cfg.ExampleQueries[0] + ")")
+		} else {
+			editor.SetPlaceholder("Enter SQL, press " + runKey + " to run")
+		}
+		historyList.Clear()
+		historyIndex = historyIndex[:0]
+		title := "History"
+		if cfg.HistoryAllProfiles {
+			title = "History (all profiles)"
+		} else if len(cfg.Profiles) > 0 {
+			title = "History (" + profileName(cfg.Profile) + ")"
+		}
+		historyList.SetTitle(title)
+		day := ""
+		shown := 0
+		for i, e := range hist.Entries {
+			if !historyShown(e) {
+				continue
+			}
+			if cfg.GroupHistoryByDay {
+				if d := historyDayLabel(e.Timestamp, time.Now()); d != day {
+					day = d
+					historyList.AddItem("[gray]── "+d+" ──", "", 0, nil)
+					historyIndex = append(historyIndex, -1)
+				}
+			}
+			label := fmt.Sprintf("%s — %s", e.Timestamp.Format("2006-01-02 15:04:05"), e.Query)
+			if cfg.HistoryAllProfiles {
+				// tag entries so queries from other profiles stand out
+				label = fmt.Sprintf("[gray]%s[-] %s", tview.Escape("["+profileName(e.Profile)+"]"), label)
+			}
+			// capture index
+			idx := i
+			historyIndex = append(historyIndex, idx)
+			historyList.AddItem(label, "", 0, func() {
+				loadFromHistory(hist.Entries[idx].Query, func() {
+					app.SetFocus(editor)
+					updateFocusColors(editor)
+				})
+			})
+			if shown++; shown > 100 {
+				break
+			}
+		}
+		if entryAt(0) < 0 && len(historyIndex) > 1 {
+			historyList.SetCurrentItem(1)
+		}
+	}
+
+	// Update history preview when selection changes
+	historyList.SetChangedFunc(func(index int, mainText string, secondaryText string, shortcut rune) {
+		// Date headers aren't selectable: step past them in the direction we were moving
+		if index >= 0 && index < len(historyIndex) && historyIndex[index] < 0 {
+			target := index + 1
+			if index < lastHistoryPos && index > 0 {
+				target = index - 1
+			}
+			app.QueueUpdateDraw(func() {
+				historyList.SetCurrentItem(target)
+			})
+			return
+		}
+		lastHistoryPos = index
+		if idx := entryAt(index); idx >= 0 && idx < len(hist.Entries) {
+			entry := hist.Entries[idx]
+			var preview strings.Builder
+			preview.WriteString(fmt.Sprintf("[yellow]Time:[white] %s\n\n", entry.Timestamp.Format("2006-01-02 15:04:05")))
+			preview.WriteString("[yellow]Query:[white]\n")
+			preview.WriteString(entry.Query)
+			historyPreview.SetText(preview.String())
+			historyPreview.ScrollToBeginning()
+		} else {
+			historyPreview.SetText("[gray]No history selected")
+		}
+	})
+
+	// resetHistoryPreview shows the selected history item in the preview, if there is one
+	resetHistoryPreview := func() {
+		if idx := entryAt(historyList.GetCurrentItem()); idx >= 0 && idx < len(hist.Entries) {
+			var preview strings.Builder
+			preview.WriteString(fmt.Sprintf("[yellow]Time:[white] %s\n\n", hist.Entries[idx].Timestamp.Format("2006-01-02 15:04:05")))
+			preview.WriteString("[yellow]Query:[white]\n")
+			preview.WriteString(hist.Entries[idx].Query)
+			historyPreview.SetText(preview.String())
+		} else if len(hist.Entries) > 0 {
+			historyPreview.SetText("[gray]No history for this profile (press A to show all profiles)")
+		} else {
+			historyPreview.SetText(examplesText())
+		}
+	}
+
+	refreshHistoryList()
+	resetHistoryPreview()
+
+	// replayOnly reports, in the status bar, that queries can't run while browsing an --open file
+	replayOnly := func() bool {
+		if replayPath == "" {
+			return false
+		}
+		setStatus("[yellow]Browsing %s offline; running queries is disabled", tview.Escape(filepath.Base(replayPath)))
+		return true
+	}
+
+	// toast confirms something in a box over the bottom-right corner for toast_timeout_ms,
+	// with room for the lines the status bar would cut off; the status bar gets the first line.
+	// The text is plain, not tagged.
+	var toastLines []string
+	toastSeq := 0
+	toast := func(format string, a ...interface{}) {
+		text := fmt.Sprintf(format, a...)
+		first, _, _ := strings.Cut(text, "\n")
+		setStatus("[green]%s", tview.Escape(first))
+		if cfg.ToastTimeoutMs <= 0 {
+			return
+		}
+		toastLines = strings.Split(text, "\n")
+		toastSeq++
+		seq := toastSeq
+		time.AfterFunc(time.Duration(cfg.ToastTimeoutMs)*time.Millisecond, func() {
+			app.QueueUpdateDraw(func() {
+				if seq == toastSeq {
+					toastLines = nil
+				}
+			})
+		})
+	}
+	app.SetAfterDrawFunc(func(screen tcell.Screen) {
+		if len(toastLines) == 0 {
+			return
+		}
+		w, h := screen.Size()
+		inner := 0
+		for _, l := range toastLines {
+			inner = max(inner, utf8.RuneCountInString(l))
+		}
+		inner = min(inner, max(w-6, 10))
+		var lines []string
+		for _, l := range toastLines {
+			lines = append(lines, wrapText(l, inner)...)
+		}
+		lines = lines[:min(len(lines), max(h-6, 1))]
+		// above the status bar, against the right edge
+		bw, bh := inner+4, len(lines)+2
+		x, y := max(w-bw-1, 0), max(h-bh-2, 0)
+		style := tcell.StyleDefault.Background(tview.Styles.ContrastBackgroundColor).Foreground(tcell.ColorGreen)
+		for dy := 0; dy < bh; dy++ {
+			for dx := 0; dx < bw; dx++ {
+				ch := ' '
+				switch {
+				case dy == 0 && dx == 0:
+					ch = tview.Borders.TopLeft
+				case dy == 0 && dx == bw-1:
+					ch = tview.Borders.TopRight
+				case dy == bh-1 && dx == 0:
+					ch = tview.Borders.BottomLeft
+				case dy == bh-1 && dx == bw-1:
+					ch = tview.Borders.BottomRight
+				case dy == 0 || dy == bh-1:
+					ch = tview.Borders.Horizontal
+				case dx == 0 || dx == bw-1:
+					ch = tview.Borders.Vertical
+				}
+				screen.SetContent(x+dx, y+dy, ch, nil, style)
+			}
+		}
+		for i, l := range lines {
+			for j, r := range []rune(l) {
+				screen.SetContent(x+2+j, y+1+i, r, nil, style.Foreground(tview.Styles.PrimaryTextColor))
+			}
+		}
+	})
+
+	// saveHistorySoon saves history after HistorySaveIntervalMs, batching the changes made in
+	// the meantime; without an interval it saves right away. Pending changes are flushed on exit.
+	// It does nothing when auto-save is off.
+	var historySaveTimer *time.Timer
+	saveHistorySoon := func() error {
+		if !cfg.AutoSaveHistory {
+			return nil
+		}
+		if cfg.HistorySaveIntervalMs <= 0 {
+			return saveHistory(saved)
+		}
+		if historySaveTimer == nil {
+			historySaveTimer = time.AfterFunc(time.Duration(cfg.HistorySaveIntervalMs)*time.Millisecond, func() {
+				app.QueueUpdateDraw(func() {
+					historySaveTimer = nil
+					if err := saveHistory(saved); err != nil {
+						setStatus("[red]Failed to save history: %v", err)
+					}
+				})
+			})
+		}
+		return nil
+	}
+
+	// Add input handler for history list to delete entries with 'd'
+	historyList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() == 'd' || event.Rune() == 'D' {
+			currentItem := historyList.GetCurrentItem()
+			if idx := entryAt(currentItem); idx >= 0 && idx < len(hist.Entries) {
+				// Remove the entry from history
+				removed := hist.Entries[idx]
+				hist.Entries = append(hist.Entries[:idx], hist.Entries[idx+1:]...)
+				// Save updated history
+				err := saveHistorySoon()
+				if saved != hist {
+					// without auto-save, deleting an entry that is on disk removes it there too.
+					// Timestamps differ once either copy is re-run, so match on query and profile.
+					i := slices.IndexFunc(saved.Entries, func(e HistoryEntry) bool {
+						return e.Query == removed.Query && e.Profile == removed.Profile
+					})
+					if i >= 0 {
+						saved.Entries = slices.Delete(saved.Entries, i, i+1)
+						err = saveHistory(saved)
+					}
+				}
+				if err != nil {
+					setStatus("[red]Failed to save history: %v", err)
+				} else {
+					// Refresh the list
+					refreshHistoryList()
+					// Try to select the same position or the last item
+					newCount := historyList.GetItemCount()
+					if newCount > 0 {
+						if currentItem >= newCount {
+							currentItem = newCount - 1
+						}
+						// Land on an entry rather than a date header
+						if entryAt(currentItem) < 0 {
+							if entryAt(currentItem+1) >= 0 {
+								currentItem++
+							} else {
+								currentItem--
+							}
+						}
+						historyList.SetCurrentItem(currentItem)
+					} else {
+						historyPreview.SetText(examplesText())
+					}
+					setStatus("[green]History entry deleted")
+				}
+			}
+			return nil
+		}
+		return event
+	})
+
+	currentRowCount := 0
+	var currentData []map[string]interface{}
+	var allData []map[string]interface{}     // currentData before column filters
+	var serverOrder []map[string]interface{} // allData in the order the server returned it
+	unsortedFrom := ""                       // column of the sort undone with o, to redo it
+	unsortedAsc := true
+	var columnFilters []columnFilter
+	var currentColumns []string
+	sortColumn := -1
+	sortAscending := true
+	currentQuery := ""
+	selections := make(map[string]rowSelection)
+	columnOrders := loadColumnOrders()
+	jsonCollapsed := false // collapse JSON fields in the detail view
+	showFooter := false    // show the aggregate footer under the results
+	bookmarks := make(map[string]bool)
+	rawText, rawIsJSON := "", false
+	// indent JSON in the raw view; the exact bytes are kept in rawText
+	rawPretty := cfg.PrettyRaw
+
+	// rowID identifies a data row by its primary key, or by its contents when there is none
+	rowID := func(i int) string {
+		if pk := primaryKeyColumn(currentColumns); pk != "" {
+			return fmt.Sprintf("pk:%v", currentData[i][pk])
+		}
+		return fmt.Sprintf("row:%x", rowHash(currentData[i]))
+	}
+	knownColumns := make(map[string][]string) // last columns seen per query, for empty results
+	var baseline []map[string]interface{}
+	baselineKey := ""
+	diffSummary := ""
+	resultSource := ""              // profile name when showing a result from a multi-profile run
+	var resultElapsed time.Duration // how long the shown result took to fetch (0 when unknown)
+	var profileResults []profileResult
+	var untransposed *resultView // set while the results show a transposed view
+	var grouped *groupView       // set while the results are grouped by a column
+	// / search: highlighted in place, n/N jump between matching rows
+	searchTerm, searchMatches := "", 0
+	// V range: table rows from rangeAnchor to the cursor, 0 when no range is being selected
+	rangeAnchor := 0
+	var rangePainted [2]int
+	// auto-refresh: stopRefresh is closed to end the ticker, nil while off; refreshPending is
+	// set while a refresh run is in flight and refreshAt is when the next one starts
+	var stopRefresh chan struct{}
+	var refreshAt time.Time
+	refreshPending, refreshRun := false, false
+	// startupRun marks the startup_query run, which keeps the startup focus and skips history
+	startupRun := false
+	pinnedColumns := cfg.PinnedColumns
+	var recentErrors []queryError // newest first
+	runSeq := 0                   // bumped per query so stale progress updates are dropped
+	loading := false              // showing a partial result while rows stream in
+	// stops the running stream_url query, if any
+	var stopStream context.CancelFunc
+
+	// updateResultsTitle sets the results title from the row count, sort and diff state
+	updateResultsTitle := func() {
+		title := fmt.Sprintf("Results (%d rows)", currentRowCount)
+		if len(columnFilters) > 0 {
+			where := make([]string, len(columnFilters))
+			for i, f := range columnFilters {
+				where[i] = f.String()
+			}
+			mode := "ignoring case"
+			if cfg.FilterCaseSensitive {
+				mode = "case-sensitive"
+			}
+			title = fmt.Sprintf("Results (%d of %d rows) [where %s, %s]", currentRowCount, len(allData), tview.Escape(strings.Join(where, " AND ")), mode)
+		}
+		if sortColumn >= 0 && sortColumn < len(currentColumns) {
+			title += fmt.Sprintf(" [sorted by %s %s]", currentColumns[sortColumn], map[bool]string{true: "↑", false: "↓"}[sortAscending])
+		}
+		if diffSummary != "" {
+			title += " " + diffSummary
+		}
+		if resultSource != "" {
+			title += " @" + resultSource
+		}
+		if untransposed != nil {
+			title += " [transposed]"
+		}
+		if grouped != nil {
+			title += fmt.Sprintf(" [grouped by %s]", grouped.Column)
+		}
+		if searchTerm != "" {
+			title += fmt.Sprintf(" [/%s: %d rows]", tview.Escape(searchTerm), searchMatches)
+		}
+		if pinnedColumns > 0 {
+			title += fmt.Sprintf(" [%d pinned]", pinnedColumns)
+		}
+		if stopRefresh != nil {
+			if refreshPending {
+				title += " [⟳ refreshing]"
+			} else {
+				title += fmt.Sprintf(" [⟳ in %ds]", max(int(math.Ceil(time.Until(refreshAt).Seconds())), 0))
+			}
+		}
+		if _, offset, ok := queryWindow(currentQuery); ok && len(allData) > 0 {
+			title += fmt.Sprintf(" [rows %d-%d]", offset+1, offset+len(allData))
+		}
+		// where the cursor is across wide tables
+		if _, col := resultsTable.GetSelection(); len(currentColumns) > 1 && col < len(currentColumns) {
+			title += fmt.Sprintf(" [col %d/%d]", col+1, len(currentColumns))
+		}
+		resultsTable.SetTitle(title)
+		cardsView.SetTitle(title)
+	}
+
+	// stopAutoRefresh turns auto-refresh off, saying why when there's a reason
+	stopAutoRefresh := func(reason string) {
+		if stopRefresh == nil {
+			return
+		}
+		close(stopRefresh)
+		stopRefresh, refreshPending = nil, false
+		updateResultsTitle()
+		if reason != "" {
+			setStatus("[yellow]Auto-refresh stopped: %s", reason)
+		}
+	}
+
+	// rowRange returns the table rows of the V range, in order
+	rowRange := func() (int, int, bool) {
+		row, _ := resultsTable.GetSelection()
+		if rangeAnchor == 0 || len(currentData) == 0 || grouped != nil || untransposed != nil {
+			return 0, 0, false
+		}
+		lo, hi := min(rangeAnchor, row), max(rangeAnchor, row)
+		return max(lo, 1), min(hi, len(currentData)), true
+	}
+
+	// paintRange underlines the rows of the V range, clearing the previously underlined rows
+	paintRange := func() {
+		for r := rangePainted[0]; r > 0 && r <= rangePainted[1]; r++ {
+			for c := range currentColumns {
+				cell := resultsTable.GetCell(r, c)
+				cell.SetAttributes(cell.Attributes &^ tcell.AttrUnderline)
+			}
+		}
+		rangePainted = [2]int{}
+		lo, hi, ok := rowRange()
+		if !ok {
+			return
+		}
+		for r := lo; r <= hi; r++ {
+			for c := range currentColumns {
+				cell := resultsTable.GetCell(r, c)
+				cell.SetAttributes(cell.Attributes | tcell.AttrUnderline)
+			}
+		}
+		rangePainted = [2]int{lo, hi}
+	}
+
+	// renderGroups replaces the rendered rows with a line per group, each followed by its rows
+	// when expanded, keeping the header and column widths renderJSONToTable chose
+	renderGroups := func() {
+		header := make([]*tview.TableCell, len(currentColumns))
+		for c := range currentColumns {
+			header[c] = resultsTable.GetCell(0, c)
+		}
+		resultsTable.Clear()
+		for c, cell := range header {
+			resultsTable.SetCell(0, c, cell)
+		}
+		grouped.Lines = grouped.Lines[:0]
+		for _, g := range groupRows(currentData, grouped.Column) {
+			arrow := "▸"
+			if grouped.Expanded[g.Key] {
+				arrow = "▾"
+			}
+			label, _ := cellValue(g.Value, cfg)
+			if g.Value == nil {
+				label = "(null)"
+			}
+			text := fmt.Sprintf("%s %s = %s (%d rows)", arrow, grouped.Column, label, len(g.Rows))
+			resultsTable.SetCell(len(grouped.Lines)+1, 0, tview.NewTableCell(text).SetTextColor(tcell.ColorYellow).SetAttributes(tcell.AttrBold))
+			grouped.Lines = append(grouped.Lines, groupLine{Group: g})
+			if !grouped.Expanded[g.Key] {
+				continue
+			}
+			for i, row := range g.Rows {
+				r := len(grouped.Lines) + 1
+				for c, k := range currentColumns {
+					s, color := cellValue(row[k], cfg)
+					if rc, ok := ruleColor(cfg.ColorRules, k, row[k]); ok {
+						color = rc
+					}
+					if width := header[c].MaxWidth; len(s) > width {
+						s = truncateString(s, width, cfg.TruncateMode, cfg.EllipsisStr)
+					}
+					resultsTable.SetCell(r, c, tview.NewTableCell(s).SetMaxWidth(header[c].MaxWidth).SetTextColor(color))
+				}
+				grouped.Lines = append(grouped.Lines, groupLine{Group: g, Row: row, Index: i})
+			}
+		}
+		updateResultsTitle()
+	}
+
+	// renderResults redraws currentData into the table, highlighting differences from the baseline
+	var renderCards func()
+	renderResults := func() {
+		defer renderCards()
+		// showRemoved lists the baseline rows missing from the results after the data rows,
+		// where they can't be selected
+		showRemoved := func(d diffResult) {
+			for i, row := range d.Removed {
+				for c, k := range currentColumns {
+					width := resultsTable.GetCell(0, c).MaxWidth
+					s := formatValue(row[k], cfg)
+					if len(s) > width {
+						s = truncateString(s, width, cfg.TruncateMode, cfg.EllipsisStr)
+					}
+					cell := tview.NewTableCell(s).SetMaxWidth(width).SetTextColor(tcell.ColorRed).SetSelectable(false)
+					resultsTable.SetCell(len(currentData)+1+i, c, cell)
+				}
+			}
+			diffSummary = fmt.Sprintf("[diff by %s: +%d -%d ~%d]", baselineKey, d.Added, len(d.Removed), d.Changed)
+		}
+		diffSummary = ""
+		if len(currentData) == 0 {
+			// Keep the header row for empty results when we know the columns
+			resultsTable.Clear()
+			cols := knownColumns[currentQuery]
+			if cols == nil {
+				cols = selectColumns(currentQuery)
+			}
+			// every baseline row is removed; show them in the baseline's columns if need be
+			if cols == nil && len(baseline) > 0 {
+				cols = dataColumns(baseline)
+			}
+			currentColumns = cols
+			for c, k := range cols {
+				resultsTable.SetCell(0, c, headerCell(k, cfg.MaxColumnWidth))
+			}
+			if baseline != nil {
+				showRemoved(diffRows(baseline, currentData, baselineKey))
+			}
+			updateResultsTitle()
+			return
+		}
+		cols := dataColumns(currentData)
+		// an order set with </> wins over the query's own
+		order := columnOrders[columnSignature(cols)]
+		if order == nil && cfg.QueryColumnOrder {
+			order = queryColumnOrder(currentQuery, cols)
+		}
+		renderJSONToTable(currentData, resultsTable, &currentColumns, order, cfg)
+		knownColumns[currentQuery] = currentColumns
+		if grouped != nil {
+			renderGroups()
+			return
+		}
+		if baseline != nil {
+			d := diffRows(baseline, currentData, baselineKey)
+			colors := map[rowDiff]tcell.Color{diffAdded: tcell.ColorGreen, diffChanged: tcell.ColorYellow}
+			for r, st := range d.Status {
+				if st == diffSame {
+					continue
+				}
+				for c := range currentColumns {
+					if cell := resultsTable.GetCell(r+1, c); cell != nil {
+						cell.SetTextColor(colors[st])
+					}
+				}
+			}
+			showRemoved(d)
+		}
+		// Highlight cells matching the search
+		searchMatches = 0
+		if searchTerm != "" {
+			for i, row := range currentData {
+				hits := searchColumns(row, currentColumns, searchTerm, cfg.FilterCaseSensitive)
+				for _, c := range hits {
+					resultsTable.GetCell(i+1, c).SetBackgroundColor(tcell.ColorDarkBlue)
+				}
+				if len(hits) > 0 {
+					searchMatches++
+				}
+			}
+		}
+		// Mark bookmarked rows in the first column
+		if len(bookmarks) > 0 {
+			for i := range currentData {
+				if bookmarks[rowID(i)] {
+					cell := resultsTable.GetCell(i+1, 0)
+					cell.SetText("★" + cell.Text).SetTextColor(tcell.ColorYellow)
+				}
+			}
+		}
+		rangePainted = [2]int{}
+		paintRange()
+		// Aggregate footer: count/sum/min/max/avg for numeric columns, true/false counts for
+		// boolean columns, distinct counts otherwise
+		if showFooter {
+			first := resultsTable.GetRowCount()
+			for c, k := range currentColumns {
+				st := computeStats(currentData, k)
+				lines := []string{fmt.Sprintf("distinct=%d", st.Distinct), "", "", "", ""}
+				if st.Numeric {
+					lines = []string{
+						fmt.Sprintf("n=%d", st.Count),
+						"Σ=" + formatValue(st.Sum, cfg),
+						"min=" + formatValue(st.Min, cfg),
+						"max=" + formatValue(st.Max, cfg),
+						"avg=" + formatValue(math.Round(st.Avg()*100)/100, cfg),
+					}
+				} else if st.Boolean {
+					lines = []string{
+						fmt.Sprintf("n=%d", st.Count),
+						fmt.Sprintf("true=%d", st.True),
+						fmt.Sprintf("false=%d", st.False),
+						"", "",
+					}
+				}
+				width := resultsTable.GetCell(0, c).MaxWidth
+				for i, text := range lines {
+					if len(text) > width {
+						text = truncateString(text, width, cfg.TruncateMode, cfg.EllipsisStr)
+					}
+					cell := tview.NewTableCell(text).SetMaxWidth(width).SetTextColor(tcell.ColorAqua).SetSelectable(false)
+					resultsTable.SetCell(first+i, c, cell)
+				}
+			}
+		}
+		updateResultsTitle()
+	}
+
+	// restoreSelection selects the row remembered for the current query, or the first row
+	restoreSelection := func(col int) {
+		sel, ok := selections[currentQuery]
+		if !ok || sel.Shape != columnSignature(currentColumns) {
+			resultsTable.Select(1, max(col, 0))
+			return
+		}
+		if col < 0 {
+			col = sel.Col
+		}
+		resultsTable.Select(findSelectedRow(currentData, currentColumns, sel)+1, col)
+	}
+
+	// expandedField is shown in full in the detail view while row expandedRow stays selected
+	expandedField, expandedRow := "", 0
+
+	// detailFields renders the given fields of a row as the detail view shows them; fields in
+	// differs get a red name, and fields missing from the row are marked as such
+	detailFields := func(rowData map[string]interface{}, keys []string, differs map[string]bool) string {
+		var details strings.Builder
+		for _, k := range keys {
+			name := "[yellow]" + k + ":"
+			if differs[k] {
+				name = "[red::b]" + k + ":[-::-]"
+			}
+			v, present := rowData[k]
+			if !present {
+				details.WriteString(fmt.Sprintf("%s[gray] (missing)[white]\n", name))
+				continue
+			}
+			// JSON objects/arrays are pretty-printed (or summarized when collapsed)
+			if parsed, ok := asJSON(v); ok {
+				if jsonCollapsed {
+					details.WriteString(fmt.Sprintf("%s[gray] %s[white]\n", name, jsonSummary(parsed)))
+				} else {
+					details.WriteString(fmt.Sprintf("%s[white] %s\n", name, highlightJSON(parsed, "")))
+				}
+				continue
+			}
+			if n, ok := blobSize(v); ok {
+				details.WriteString(fmt.Sprintf("%s[gray] %s (press d to decode)[white]\n", name, tview.Escape(blobSummary(n))))
+				continue
+			}
+			valStr := formatValue(v, cfg)
+			// Compact display: field: value, cut at the configured length unless expanded
+			if r := []rune(valStr); cfg.DetailMaxValueLen > 0 && len(r) > cfg.DetailMaxValueLen && k != expandedField {
+				more := fmt.Sprintf("[… %d more chars, press e to expand]", len(r)-cfg.DetailMaxValueLen)
+				valStr = tview.Escape(string(r[:cfg.DetailMaxValueLen])) + " [gray]" + tview.Escape(more) + "[white]"
+			} else {
+				valStr = tview.Escape(valStr)
+			}
+			details.WriteString(fmt.Sprintf("%s[white] %s\n", name, valStr))
+		}
+		return details.String()
+	}
+
+	// dataIndex maps a results table row to its index in currentData. The header, grouped lines,
+	// removed diff rows and the footer have none.
+	dataIndex := func(row int) (int, bool) {
+		if grouped != nil || row < 1 || row > len(currentData) {
+			return 0, false
+		}
+		return row - 1, true
+	}
+
+	// highlightCard marks the selected row's card and scrolls it into view
+	highlightCard := func() {
+		row, _ := resultsTable.GetSelection()
+		if i, ok := dataIndex(row); cardMode && ok {
+			cardsView.Highlight(strconv.Itoa(i)).ScrollToHighlight()
+		}
+	}
+
+	// renderCards redraws the card view from the displayed rows. Grouped and transposed results
+	// are always shown as a table.
+	cardsWidth := 0
+	renderCards = func() {
+		if !cardMode || grouped != nil || untransposed != nil {
+			resultsSlot.SwitchToPage("table")
+			return
+		}
+		resultsSlot.SwitchToPage("cards")
+		_, _, width, _ := resultsSlot.GetRect()
+		width = max(width-2, 20)
+		cardsWidth = width
+		var b strings.Builder
+		for i, row := range currentData {
+			heading := fmt.Sprintf("┌─ Row %d/%d ", i+1, len(currentData))
+			fmt.Fprintf(&b, "[\"%d\"][aqua]%s%s[-][\"\"]\n", i, heading, strings.Repeat("─", max(width-utf8.RuneCountInString(heading), 0)))
+			for _, line := range strings.Split(strings.TrimRight(detailFields(row, currentColumns, nil), "\n"), "\n") {
+				b.WriteString("[aqua]│[-] " + line + "\n")
+			}
+			b.WriteString("[aqua]└" + strings.Repeat("─", width-1) + "[-]\n")
+		}
+		if len(currentData) == 0 {
+			b.WriteString("[yellow]No results")
+		}
+		cardsView.SetText(b.String())
+		cardsView.Highlight()
+		highlightCard()
+	}
+
+	// cards span the pane, so redraw them when it changes width
+	cardsView.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		if cardMode && width-2 != cardsWidth {
+			cardsWidth = width - 2
+			app.QueueUpdateDraw(renderCards)
+		}
+		return x + 1, y + 1, width - 2, height - 2
+	})
+
+	// toggleCards switches the results between the table and the card view
+	toggleCards := func() {
+		cardMode = !cardMode
+		renderCards()
+		if cardMode {
+			setStatus("[green]Results shown as cards (K for the table)")
+		} else {
+			setStatus("[green]Results shown as a table")
+		}
+	}
+
+	// Function to update detail view based on selected row
+	updateDetailView := func() {
+		detailSlot.SwitchToPage("text")
+		row, _ := resultsTable.GetSelection()
+		var rowData map[string]interface{}
+		heading := fmt.Sprintf("Row %d/%d", row, len(currentData))
+		if grouped != nil {
+			if row <= 0 || row > len(grouped.Lines) {
+				detailView.SetText("[yellow]No row selected")
+				return
+			}
+			line := grouped.Lines[row-1]
+			if line.Row == nil {
+				label, _ := cellValue(line.Group.Value, cfg)
+				detailView.SetText(fmt.Sprintf("[yellow::b]%s = %s[white]\n%d rows (Enter to expand or collapse)", grouped.Column, tview.Escape(label), len(line.Group.Rows)))
+				return
+			}
+			rowData = line.Row
+			heading = fmt.Sprintf("Row %d/%d of group", line.Index+1, len(line.Group.Rows))
+		} else {
+			i, ok := dataIndex(row)
+			if !ok {
+				detailView.SetText("[yellow]No row selected")
+				return
+			}
+			rowData = currentData[i]
+		}
+		if row != expandedRow {
+			expandedField = ""
+		}
+		// Get keys in sorted order for consistent display
+		keys := make([]string, 0, len(rowData))
+		for k := range rowData {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		
+		if cfg.DetailTable {
+			// the row transposed to field | value rows, drawn like the results; the value column
+			// may use the width of the pane
+			_, _, width, _ := detailSlot.GetRect()
+			nameWidth := len("field")
+			for _, k := range keys {
+				nameWidth = max(nameWidth, len(k))
+			}
+			valueWidth := max(width-nameWidth-3, cfg.MaxColumnWidth)
+			tableCfg := *cfg
+			tableCfg.MaxColumnWidth = valueWidth
+			var cols []string
+			renderJSONToTable(transposeRows([]map[string]interface{}{rowData}, keys), detailGrid, &cols, []string{"field", "value"}, &tableCfg)
+			// longer values wrap onto rows below, up to detail_row_height, the last one marked
+			// when the value goes on; bottom up so the rows still to do keep their place
+			vc := slices.Index(cols, "value")
+			for i := len(keys) - 1; i >= 0; i-- {
+				text, _ := cellValue(rowData[keys[i]], cfg)
+				lines := wrapText(text, valueWidth)
+				if len(lines) < 2 {
+					continue
+				}
+				if h := cfg.DetailRowHeight; h > 0 && len(lines) > h {
+					lines = lines[:h]
+					last := []rune(lines[h-1])
+					keep := max(valueWidth-utf8.RuneCountInString(cfg.EllipsisStr), 0)
+					lines[h-1] = string(last[:min(len(last), keep)]) + cfg.EllipsisStr
+				}
+				cell := detailGrid.GetCell(i+1, vc)
+				cell.SetText(lines[0])
+				for j := len(lines) - 1; j > 0; j-- {
+					detailGrid.InsertRow(i + 2)
+					detailGrid.SetCell(i+2, 1-vc, tview.NewTableCell(""))
+					detailGrid.SetCell(i+2, vc, tview.NewTableCell(lines[j]).SetMaxWidth(cell.MaxWidth).SetTextColor(cell.Color))
+				}
+			}
+			detailGrid.SetTitle("Detail: " + heading)
+			detailGrid.ScrollToBeginning()
+			detailSlot.SwitchToPage("table")
+			return
+		}
+		var details strings.Builder
+		details.WriteString(fmt.Sprintf("[yellow::b]%s[white]\n", heading))
+		details.WriteString(detailFields(rowData, keys, nil))
+		detailView.SetText(details.String())
+		detailView.ScrollToBeginning()
+	}
+
+	// Setup selection changed handler for results table
+	resultsTable.SetSelectionChangedFunc(func(row, col int) {
+		// End, clicks and restored selections may land under the data; keep to the data rows
+		if last := lastSelectableRow(resultsTable); row > last {
+			resultsTable.Select(last, col)
+			return
+		}
+		if !loading {
+			updateResultsTitle()
+		}
+		highlightCard()
+		if rangeAnchor > 0 {
+			paintRange()
+			if lo, hi, ok := rowRange(); ok {
+				setStatus("[green]%d rows selected (%d-%d): y to copy, V to clear", hi-lo+1, lo, hi)
+			}
+		}
+		if untransposed != nil || grouped != nil {
+			updateDetailView()
+			return
+		}
+		if i, ok := dataIndex(row); ok && !loading {
+			// Remember the selection so sorting or re-running keeps our place
+			sel := rowSelection{Index: i, Col: col, Shape: columnSignature(currentColumns)}
+			if pk := primaryKeyColumn(currentColumns); pk != "" {
+				sel.Key = fmt.Sprintf("%v", currentData[i][pk])
+			}
+			selections[currentQuery] = sel
+			updateDetailView()
+		}
+	})
+
+	// Setup click handler for column sorting
+	resultsTable.SetSelectedFunc(func(row, col int) {
+		if grouped != nil && row > 0 && row <= len(grouped.Lines) {
+			// Enter on a grouped line expands or collapses its group, keeping the cursor on it
+			key := grouped.Lines[row-1].Group.Key
+			grouped.Expanded[key] = !grouped.Expanded[key]
+			renderResults()
+			for i, l := range grouped.Lines {
+				if l.Row == nil && l.Group.Key == key {
+					resultsTable.Select(i+1, col)
+					break
+				}
+			}
+			updateDetailView()
+			return
+		}
+		if row == 0 && len(currentData) > 0 && col < len(currentColumns) {
+			// Clicked on header - sort by this column
+			colName := currentColumns[col]
+			
+			// Toggle sort direction if same column
+			if sortColumn == col {
+				sortAscending = !sortAscending
+			} else {
+				sortColumn = col
+				sortAscending = true
+			}
+			
+			// Sort the data (and the unfiltered rows, so clearing filters keeps the order)
+			sortRows(currentData, colName, sortAscending)
+			if len(columnFilters) > 0 {
+				sortRows(allData, colName, sortAscending)
+			}
+			
+			// Re-render table
+			renderResults()
+			restoreSelection(col)
+			updateDetailView()
+		}
+	})
+
+	// checkConnection probes the API at base once and updates the indicator; call it off the UI
+	// goroutine with the config taken on it. Changes after the first check are announced in the
+	// status bar.
+	connState := ""
+	checkConnection := func(c *Config, base string) {
+		req, err := http.NewRequest(http.MethodGet, withParams(c, healthCheckURL(base)), nil)
+		var resp *http.Response
+		if err == nil {
+			applyHeaders(c, req)
+			resp, err = http.DefaultClient.Do(req)
+		}
+		app.QueueUpdateDraw(func() {
+			state := "disconnected"
+			if err == nil && resp != nil {
+				resp.Body.Close()
+				if resp.StatusCode < 500 {
+					state = "connected"
+					connectionStatus.SetText("[green]●[white] Connected")
+				} else {
+					state = "server-error"
+					connectionStatus.SetText("[yellow]●[white] Server Error")
+				}
+			} else {
+				connectionStatus.SetText("[red]●[white] Disconnected")
+			}
+			if connState != "" && state != connState {
+				switch state {
+				case "connected":
+					setStatus("[green]Connection to API restored")
+				case "server-error":
+					setStatus("[yellow]API is returning server errors")
+				default:
+					setStatus("[red]Lost connection to API")
+				}
+			}
+			connState = state
+		})
+	}
+
+	// updateEditorTitle marks the editor when its query no longer matches the displayed results
+	updateEditorTitle := func() {
+		title := "Editor"
+		if replayPath != "" {
+			title = "Editor (read-only)"
+		} else if currentQuery != "" {
+			q, err := expandAlias(cfg, editor.GetText())
+			if err != nil || strings.Join(strings.Fields(prepareQuery(cfg, q)), " ") != strings.Join(strings.Fields(currentQuery), " ") {
+				title = "Editor [yellow](edited — press " + runKey + " to re-run)[white]"
+			}
+		}
+		if editor.GetTitle() != title {
+			editor.SetTitle(title)
+		}
+	}
+	// Up/Down recall: recallIndex is the history entry shown in the editor (-1 = the user's own
+	// text, kept in recallDraft); typing ends the recall
+	recallIndex, recallDraft, recalling := -1, "", false
+	editor.SetChangedFunc(func() {
+		if !recalling {
+			recallIndex = -1
+		}
+		stopAutoRefresh("the query was edited")
+		updateEditorTitle()
+	})
+
+	// recallHistory steps through history in the editor: older (+1) or newer (-1)
+	recallHistory := func(delta int) bool {
+		entries := slices.DeleteFunc(slices.Clone(hist.Entries), func(e HistoryEntry) bool { return !historyShown(e) })
+		next := recallIndex + delta
+		// skip the newest entry when it's what the editor already holds
+		if recallIndex == -1 && delta > 0 && len(entries) > 0 && strings.TrimSpace(editor.GetText()) == entries[0].Query {
+			next++
+		}
+		if next < -1 || next >= len(entries) || (recallIndex == -1 && delta < 0) {
+			return false
+		}
+		if recallIndex == -1 {
+			recallDraft = editor.GetText()
+		}
+		text := recallDraft
+		if next >= 0 {
+			text = entries[next].Query
+		}
+		recalling = true
+		editor.SetText(text, true)
+		recalling = false
+		recallIndex = next
+		if next >= 0 {
+			setStatus("[green]History %d/%d (Up/Down to step, type to edit)", next+1, len(entries))
+		} else {
+			setStatus("[green]Back to your query")
+		}
+		return true
+	}
+
+	// setResultQuery records the query behind the displayed results in the top bar
+	setResultQuery := func(query string) {
+		currentQuery = strings.TrimSpace(query)
+		resultQueryView.SetText(tview.Escape(strings.Join(strings.Fields(currentQuery), " ")))
+		if limit, offset, ok := queryWindow(currentQuery); ok {
+			windowView.SetText(fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset))
+		} else {
+			windowView.SetText("[gray]no LIMIT")
+		}
+		updateEditorTitle()
+	}
+
+	// showRaw displays rawText in the raw view, indented when it is JSON and pretty-printing is on
+	showRaw := func() {
+		text, title := rawText, "Raw Output"
+		if rawIsJSON && rawPretty {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, []byte(rawText), "", "  "); err == nil {
+				text, title = buf.String(), "Raw Output (pretty)"
+			}
+		}
+		rawView.SetTitle(title)
+		rawView.SetText(tview.Escape(text))
+	}
+
+	// showResult displays a fetched result in the results, detail and raw panes
+	showResult := func(res interface{}, kind, raw string, err error) {
+		untransposed = nil
+		grouped = nil
+		rangeAnchor = 0
+		if err != nil {
+			setStatus("[red]Error: %v", err)
+			rawText, rawIsJSON = fmt.Sprintf("Error: %v", err), false
+			showRaw()
+			rawView.ScrollToBeginning()
+			return
+		}
+
+		detailSlot.SwitchToPage("text")
+		// Always show raw output
+		rawText, rawIsJSON = raw, kind == "json"
+		showRaw()
+		rawView.ScrollToBeginning()
+
+		if kind == "json" {
+			// try cast to []map[string]interface{}
+			switch v := res.(type) {
+			case []map[string]interface{}:
+				allData, serverOrder = v, slices.Clone(v)
+				currentData = filterRows(v, columnFilters, cfg.FilterCaseSensitive)
+				currentRowCount = len(currentData)
+				renderResults()
+				if currentRowCount > 0 {
+					restoreSelection(-1)
+					updateDetailView()
+				} else {
+					detailView.SetText("[yellow]No results")
+				}
+				setStatus("[green]Fetched %d rows", len(v))
+				return
+			case []interface{}:
+				// convert items to rows (objects, or a single column of scalars)
+				maps := normalizeRows(v)
+				if len(maps) > 0 {
+					allData, serverOrder = maps, slices.Clone(maps)
+					currentData = filterRows(maps, columnFilters, cfg.FilterCaseSensitive)
+					currentRowCount = len(currentData)
+					renderResults()
+					restoreSelection(-1)
+					updateDetailView()
+					setStatus("[green]Fetched %d rows", len(maps))
+				} else {
+					resultsTable.Clear()
+					currentData = nil
+					allData = nil
+					currentRowCount = 0
+					detailView.SetText("[yellow]JSON result (non-tabular)")
+					setStatus("[green]JSON result (non-tabular)")
+				}
+				return
+			default:
+				resultsTable.Clear()
+				currentData = nil
+				allData = nil
+				currentRowCount = 0
+				detailView.SetText("[yellow]JSON result (see raw output)")
+				setStatus("[green]JSON result")
+				return
+			}
+		}
+		// text
+		resultsTable.Clear()
+		currentData = nil
+		allData = nil
+		currentRowCount = 0
+		if kind == "malformed" {
+			detailView.SetText("[red]Malformed JSON response (see raw output)")
+			setStatus("[red]Malformed JSON response (showing raw)")
+			return
+		}
+		detailView.SetText("[yellow]Text result (see raw output)")
+		setStatus("[green]Text result")
+	}
+
+	// recordError remembers a failed query and updates the counter in the top bar
+	recordError := func(e queryError) {
+		recentErrors = append([]queryError{e}, recentErrors...)
+		if len(recentErrors) > maxRecentErrors {
+			recentErrors = recentErrors[:maxRecentErrors]
+		}
+		errorsView.SetText(fmt.Sprintf("[red]✖ %d", len(recentErrors)))
+		topBar.ResizeItem(errorsView, 10, 0)
+	}
+
+	// showTransposed swaps the results for a field-per-row view of rows, which are every row or
+	// the one with rowID id
+	showTransposed := func(rows []map[string]interface{}, id string) {
+		untransposed = &resultView{currentData, allData, columnFilters, sortColumn, sortAscending, id}
+		currentData = transposeRows(rows, currentColumns)
+		allData = currentData
+		columnFilters = nil
+		sortColumn, sortAscending = -1, true
+		currentRowCount = len(currentData)
+		renderResults()
+		resultsTable.Select(1, 0)
+		updateDetailView()
+	}
+
+	// runQuery runs a query; refresh bypasses the response cache
+	runQuery := func(query string, refresh bool) {
+		// auto-refresh re-runs quietly: no history entry, focus change or scroll jump
+		background, startup := refreshRun, startupRun
+		refreshRun, startupRun = false, false
+		if replayOnly() {
+			if background {
+				stopAutoRefresh("")
+			}
+			return
+		}
+		expanded, err := expandAlias(cfg, query)
+		if err != nil {
+			setStatus("[red]%v", err)
+			if background {
+				stopAutoRefresh(err.Error())
+			}
+			return
+		}
+		rowOffset, colOffset := resultsTable.GetOffset()
+		if !background {
+			setStatus("[yellow]Running query...")
+		}
+		sent := prepareQuery(cfg, expanded)
+		uncommented := cfg.StripComments && stripComments(expanded) != expanded
+		stripped := sent != expanded
+		if !background {
+			sortColumn = -1 // Reset sorting
+			sortAscending = true
+			unsortedFrom = ""
+			bookmarks = make(map[string]bool)
+			columnFilters = nil
+			searchTerm = ""
+			untransposed = nil
+			grouped = nil
+			rangeAnchor = 0
+		}
+		setResultQuery(sent)
+		resultSource = ""
+
+		if !background && !startup {
+			// Auto-save to history
+			appendHistory(hist, query, cfg.Profile, cfg.MaxHistoryEntries)
+			if err := saveHistorySoon(); err != nil {
+				setStatus("[red]Failed to save history: %v", err)
+			} else {
+				refreshHistoryList()
+			}
+
+			// Focus results table immediately
+			app.SetFocus(resultsTable)
+		}
+		if !background {
+			// refreshes keep the old rows on screen until the new ones arrive
+			currentRowCount = 0
+			resultsTable.Clear()
+		}
+
+		runSeq++
+		seq := runSeq
+		// progress shows the first row as soon as it arrives and a running row count in the title
+		var lastUpdate time.Time
+		progress := func(n int, first map[string]interface{}) {
+			if first == nil && time.Since(lastUpdate) < 200*time.Millisecond {
+				return
+			}
+			lastUpdate = time.Now()
+			app.QueueUpdateDraw(func() {
+				if seq != runSeq {
+					return
+				}
+				// refreshes keep the old rows, and the view on them, until the new ones arrive
+				if first != nil && !background {
+					loading = true
+					allData = []map[string]interface{}{first}
+					currentData = allData
+					currentRowCount = 1
+					renderResults()
+					resultsTable.Select(1, 0)
+					updateDetailView()
+				}
+				resultsTable.SetTitle(fmt.Sprintf("Loading... %d rows", n))
+			})
+		}
+
+		// With a stream_url, rows are shown as the WebSocket delivers them until the server
+		// finishes or Esc stops the stream
+		if stopStream != nil {
+			stopStream()
+			stopStream = nil
+		}
+		var streamCtx context.Context
+		if cfg.StreamURL != "" && apiOverride == "" {
+			streamCtx, stopStream = context.WithCancel(context.Background())
+		}
+		streamProgress := func(rows []map[string]interface{}) {
+			if len(rows) > 1 && time.Since(lastUpdate) < 200*time.Millisecond {
+				return
+			}
+			lastUpdate = time.Now()
+			app.QueueUpdateDraw(func() {
+				if seq != runSeq {
+					return
+				}
+				first := !loading
+				loading = true
+				allData, currentData, currentRowCount = rows, rows, len(rows)
+				renderResults()
+				if first && len(rows) > 0 {
+					resultsTable.Select(1, 0)
+					updateDetailView()
+				}
+				resultsTable.SetTitle(fmt.Sprintf("Streaming... %d rows (Esc to stop)", len(rows)))
+			})
+		}
+
+		// the request works on the config as it is now; F9 may replace it while the query runs
+		c, base := cfg, apiBase
+		go func() {
+			// Without periodic checks, refresh the indicator right before querying
+			if c.ConnectionCheckSec <= 0 {
+				checkConnection(c, base)
+			}
+			start := time.Now()
+			var res interface{}
+			var kind, raw string
+			var code int
+			var cached, stopped bool
+			var err error
+			if streamCtx != nil {
+				var rows []map[string]interface{}
+				rows, raw, err = fetchStream(streamCtx, c, sent, streamProgress)
+				res, kind = rows, "json"
+				if err == context.Canceled {
+					stopped, err = true, nil
+				}
+			} else {
+				res, kind, raw, code, cached, err = fetchQueryCached(c, base, sent, refresh, progress)
+			}
+			elapsed := time.Since(start)
+			logErr := appendQueryLog(c, newQueryLogEntry(c, sent, start, res, kind, cached, err))
+
+			app.QueueUpdateDraw(func() {
+				if seq == runSeq {
+					stopStream = nil
+				}
+				if err != nil && loading {
+					// drop the partial result shown while streaming
+					resultsTable.Clear()
+					currentData, allData, currentRowCount = nil, nil, 0
+				}
+				loading = false
+				if err != nil || code >= 400 {
+					msg := strings.TrimSpace(raw)
+					if err != nil {
+						msg = err.Error()
+					}
+					recordError(queryError{Time: start, Query: sent, Code: code, Message: msg})
+				}
+				resultElapsed = elapsed
+				// auto-refresh keeps the grouping, transposed view and V range
+				group, view, anchor := grouped, untransposed, rangeAnchor
+				selRow, selCol := resultsTable.GetSelection()
+				if background && view != nil && err == nil {
+					// the new rows replace the ones under the transposed view
+					columnFilters, sortColumn, sortAscending = view.filters, view.sortCol, view.sortAsc
+				}
+				showResult(res, kind, raw, err)
+				if background && err != nil {
+					// the old rows stay on screen as they were
+					grouped, untransposed, rangeAnchor = group, view, anchor
+				}
+				if background {
+					// keep the sort the refreshed rows were shown with
+					if sortColumn >= 0 && sortColumn < len(currentColumns) {
+						sortRows(currentData, currentColumns[sortColumn], sortAscending)
+						if len(columnFilters) > 0 {
+							sortRows(allData, currentColumns[sortColumn], sortAscending)
+						}
+						renderResults()
+						restoreSelection(-1)
+						updateDetailView()
+					}
+					if err == nil && len(currentData) > 0 {
+						switch {
+						case view != nil && view.row == "":
+							showTransposed(currentData, "")
+							resultsTable.Select(selRow, selCol)
+						case view != nil:
+							for i := range currentData {
+								if rowID(i) == view.row {
+									showTransposed(currentData[i:i+1], view.row)
+									resultsTable.Select(selRow, selCol)
+									break
+								}
+							}
+						case group != nil && slices.Contains(currentColumns, group.Column):
+							grouped = &groupView{Column: group.Column, Expanded: group.Expanded}
+							renderResults()
+							resultsTable.Select(selRow, selCol)
+							updateDetailView()
+						case anchor > 0:
+							rangeAnchor = min(anchor, len(currentData))
+							paintRange()
+						}
+					}
+					refreshPending = false
+					refreshAt = time.Now().Add(time.Duration(cfg.AutoRefreshSec) * time.Second)
+					resultsTable.SetOffset(rowOffset, colOffset)
+					updateResultsTitle()
+				}
+				if stopRefresh != nil && (err != nil || code >= 400) {
+					stopAutoRefresh("")
+					status.SetText(status.GetText(false) + " [yellow](auto-refresh stopped)")
+				}
+				if cached {
+					status.SetText(status.GetText(false) + " [gray]cached")
+				}
+				if (cfg.ReadPath != "" || cfg.WritePath != "") && streamCtx == nil {
+					status.SetText(status.GetText(false) + " [gray]via " + tview.Escape(maskURL(endpointURL(routeAPI(cfg, apiBase, sent)))))
+				}
+				if stopped {
+					status.SetText(status.GetText(false) + " [yellow](stream stopped)")
+				}
+				if uncommented {
+					status.SetText(status.GetText(false) + " [gray](comments removed)")
+				} else if stripped {
+					status.SetText(status.GetText(false) + " [gray](trailing ; removed)")
+				}
+				if logErr != nil {
+					status.SetText(status.GetText(false) + " [red](query log: " + tview.Escape(logErr.Error()) + ")")
+				}
+			})
+		}()
+	}
 
-	historyPreview := tview.NewTextView().SetDynamicColors(true).SetScrollable(true).SetWordWrap(true)
-	historyPreview.SetBorder(true).SetTitle("Preview")
+	// startConnectionChecker (re)starts the periodic connection check, only when enabled
+	var stopChecker chan struct{}
+	startConnectionChecker := func() {
+		if stopChecker != nil {
+			close(stopChecker)
+			stopChecker = nil
+		}
+		if replayPath != "" {
+			connectionStatus.SetText("[gray]●[white] Offline")
+			return
+		}
+		if cfg.ConnectionCheckSec <= 0 {
+			connectionStatus.SetText("[gray]●[white] Not checked")
+			return
+		}
+		stop := make(chan struct{})
+		stopChecker = stop
+		interval := time.Duration(cfg.ConnectionCheckSec) * time.Second
+		c, base := cfg, apiBase
+		go func() {
+			for {
+				checkConnection(c, base)
+				select {
+				case <-stop:
+					return
+				case <-time.After(interval):
+				}
+			}
+		}()
+	}
+	startConnectionChecker()
 
-	queryInput := tview.NewTextView()
-	queryInput.SetBorder(true).SetTitle("Query (Press Ctrl-R to run, Ctrl-S to save to history)")
-	queryInput.SetDynamicColors(true).SetRegions(true).SetWordWrap(true)
-	// use an input capture on the page to accept typed text into the query area
+	// applyReloadedConfig re-reads config.json and applies it to the running session
+	applyReloadedConfig := func() {
+		next, err := loadConfig()
+		if err != nil {
+			setStatus("[red]Config not reloaded, keeping the current settings: %s", tview.Escape(err.Error()))
+			return
+		}
+		// Replace the config rather than overwrite it: requests still running keep the one they
+		// started with
+		old := cfg
+		cfg = next
+		apiBase = profileAPI(cfg, cfg.Profile)
+		startConnectionChecker()
+		// settings copied into the session at startup
+		ctrlEnterRuns = cfg.EnterRuns == "ctrl-enter-runs"
+		runKey = "Enter"
+		if ctrlEnterRuns {
+			runKey = "Ctrl-Enter"
+		}
+		updateEditorTitle()
+		if cfg.PrettyRaw != old.PrettyRaw {
+			rawPretty = cfg.PrettyRaw
+			showRaw()
+		}
+		if cfg.PinnedColumns != old.PinnedColumns {
+			pinnedColumns = cfg.PinnedColumns
+			resultsTable.SetFixed(1, pinnedColumns)
+			updateResultsTitle()
+		}
+		if cfg.DefaultView != old.DefaultView {
+			cardMode = cfg.DefaultView == "cards"
+			renderCards()
+		}
+		// switching profiles switches to that profile's history
+		if cfg.Profile != old.Profile || cfg.HistoryAllProfiles != old.HistoryAllProfiles {
+			refreshHistoryList()
+			resetHistoryPreview()
+		} else if cfg.EnterRuns != old.EnterRuns {
+			// the editor placeholder names the run key
+			refreshHistoryList()
+		}
+		applyLayout()
+		// re-render so column widths and value formatting follow the new settings
+		if len(currentData) > 0 {
+			renderResults()
+			updateDetailView()
+		}
+		toast("Reloaded config")
+	}
 
-	editor := tview.NewTextArea()
-	editor.SetPlaceholder("Enter SQL, press Enter to run")
-	editor.SetBorder(true).SetTitle("Editor")
+	pages.AddPage("main", flex, true, true)
 
-	resultsTable := tview.NewTable().SetFixed(1, 0).SetSelectable(true, true)
-	resultsTable.SetBorder(true).SetTitle("Results")
-	
-	// Variables for tracking key repeat for faster scrolling
-	var lastKeyTime time.Time
-	var lastKey tcell.Key
-	var keyRepeatCount int
-	
-	// Add faster scrolling for results table with acceleration
-	resultsTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+	// copyText copies text and reports where it went in the status bar
+	copyText := func(text, what string) {
+		dest, err := copyToClipboard(text)
+		if err != nil {
+			setStatus("[red]Failed to copy: %v", err)
+			return
+		}
+		toast("Copied %s to %s", what, dest)
+	}
+
+	// showCellValue pops up the full, untruncated value of the selected cell
+	showCellValue := func() {
 		row, col := resultsTable.GetSelection()
-		rowCount := resultsTable.GetRowCount()
-		
-		now := time.Now()
-		
-		switch event.Key() {
-		case tcell.KeyPgDn:
-			// Jump down by configured page step
-			newRow := row + cfg.PageScrollStep
-			if newRow >= rowCount {
-				newRow = rowCount - 1
+		i, ok := dataIndex(row)
+		if !ok || col >= len(currentColumns) {
+			setStatus("[yellow]No cell selected")
+			return
+		}
+		colName := currentColumns[col]
+		value := fullValue(currentData[i][colName])
+
+		view := tview.NewTextView().SetWrap(true).SetScrollable(true).SetText(value)
+		buttons := tview.NewForm().SetButtonsAlign(tview.AlignCenter)
+		buttons.AddButton("Copy", func() {
+			closeModal("cell", resultsTable)
+			copyText(value, fmt.Sprintf("%s value (%d chars)", colName, len(value)))
+		})
+		buttons.AddButton("Close", func() {
+			closeModal("cell", resultsTable)
+		})
+		buttons.SetCancelFunc(func() {
+			closeModal("cell", resultsTable)
+		})
+
+		box := tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(view, 0, 1, false).
+			AddItem(buttons, 3, 0, true)
+		box.SetBorder(true).SetTitle(fmt.Sprintf("%s (row %d)", colName, row))
+		showModal("cell", box, buttons, 70, 16)
+	}
+
+	// showBlob decodes the selected base64 cell and previews it as text or hex, with a path
+	// to save the raw bytes to
+	showBlob := func() {
+		row, col := resultsTable.GetSelection()
+		if row <= 0 || row > len(currentData) || col >= len(currentColumns) {
+			setStatus("[yellow]No cell selected")
+			return
+		}
+		colName := currentColumns[col]
+		b, ok := decodeBlob(currentData[row-1][colName])
+		if !ok {
+			setStatus("[yellow]%s doesn't look like base64", colName)
+			return
+		}
+		back := app.GetFocus()
+		hexDump := false
+		view := tview.NewTextView().SetWrap(true).SetScrollable(true).SetText(blobPreview(b, hexDump))
+		input := tview.NewInputField().SetLabel("Save to ").SetText(filepath.Join(cfg.ExportDir, colName+".bin")).SetFieldWidth(0)
+		form := tview.NewForm().AddFormItem(input).SetButtonsAlign(tview.AlignCenter)
+		form.AddButton("Text/Hex", func() {
+			hexDump = !hexDump
+			view.SetText(blobPreview(b, hexDump)).ScrollToBeginning()
+		})
+		form.AddButton("Save", func() {
+			path := strings.TrimSpace(input.GetText())
+			if path == "" {
+				return
+			}
+			closeModal("blob", back)
+			if err := os.WriteFile(path, b, 0o644); err != nil {
+				setStatus("[red]Failed to save %s: %v", colName, err)
+				return
+			}
+			toast("Saved %s (%s) to\n%s", colName, formatSize(len(b)), path)
+		})
+		form.AddButton("Close", func() {
+			closeModal("blob", back)
+		})
+		form.SetCancelFunc(func() {
+			closeModal("blob", back)
+		})
+
+		box := tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(view, 0, 1, false).
+			AddItem(form, 5, 0, true)
+		box.SetBorder(true).SetTitle(fmt.Sprintf("%s (row %d) %s", colName, row, tview.Escape(blobSummary(len(b)))))
+		showModal("blob", box, form, 80, 24)
+	}
+
+	// showJSONPaths lists the paths inside the selected JSON cell; Enter copies one
+	showJSONPaths := func() {
+		row, col := resultsTable.GetSelection()
+		if row <= 0 || row > len(currentData) || col >= len(currentColumns) {
+			setStatus("[yellow]No cell selected")
+			return
+		}
+		colName := currentColumns[col]
+		parsed, ok := asJSON(currentData[row-1][colName])
+		if !ok {
+			setStatus("[yellow]%s isn't a JSON object or array", colName)
+			return
+		}
+		prefix := colName
+		if !pathKeyRe.MatchString(prefix) {
+			q, _ := json.Marshal(prefix)
+			prefix = "[" + string(q) + "]"
+		}
+		paths := jsonPaths(prefix, parsed, nil)
+		if len(paths) == 0 {
+			setStatus("[yellow]%s is empty", colName)
+			return
+		}
+		back := app.GetFocus()
+		list := tview.NewList().SetSecondaryTextColor(tcell.ColorGray)
+		list.SetBorder(true).SetTitle(fmt.Sprintf("Copy a path in %s (%d)", colName, len(paths)))
+		for _, p := range paths {
+			path := p.Path
+			list.AddItem(tview.Escape(path), tview.Escape(p.Preview), 0, func() {
+				closeModal("paths", back)
+				copyText(path, "path "+path)
+			})
+		}
+		list.SetDoneFunc(func() {
+			closeModal("paths", back)
+		})
+		showModal("paths", list, list, 70, 20)
+	}
+
+	// editCellAsUpdate asks for a new value for the selected cell and loads the matching UPDATE
+	// into the editor for review. It never runs the statement.
+	editCellAsUpdate := func() {
+		row, col := resultsTable.GetSelection()
+		idx, ok := dataIndex(row)
+		if untransposed != nil || !ok || col >= len(currentColumns) {
+			setStatus("[yellow]Select a result cell to edit")
+			return
+		}
+		table := tableFromQuery(currentQuery, "")
+		if table == "" {
+			setStatus("[yellow]Can't tell which table the results come from")
+			return
+		}
+		key := primaryKeyColumn(currentColumns)
+		if cfg.PrimaryKeyColumn != "" {
+			key = ""
+			if i := slices.IndexFunc(currentColumns, func(c string) bool { return strings.EqualFold(c, cfg.PrimaryKeyColumn) }); i >= 0 {
+				key = currentColumns[i]
+			}
+		}
+		rowData, colName := currentData[idx], currentColumns[col]
+		switch {
+		case key == "":
+			setStatus("[yellow]No primary key column in the results (set primary_key_column)")
+			return
+		case key == colName:
+			setStatus("[yellow]%s is the primary key; pick another column", colName)
+			return
+		case rowData[key] == nil:
+			setStatus("[yellow]This row has no %s", key)
+			return
+		}
+		old := rowData[colName]
+		input := tview.NewInputField().SetLabel("New value ").SetText(cellText(old)).SetFieldWidth(48)
+		form := tview.NewForm().AddFormItem(input)
+		form.AddButton("Generate", func() {
+			closeModal("update", resultsTable)
+			stmt := updateStatement(table, colName, editedLiteral(input.GetText(), old), key, rowData[key])
+			loadFromHistory(stmt, func() {
+				app.SetFocus(editor)
+				updateFocusColors(editor)
+				setStatus("[green]UPDATE loaded into the editor; review it before running")
+			})
+		})
+		form.AddButton("Cancel", func() {
+			closeModal("update", resultsTable)
+		})
+		form.SetCancelFunc(func() {
+			closeModal("update", resultsTable)
+		})
+		form.SetBorder(true).SetTitle(fmt.Sprintf("UPDATE %s.%s where %s = %s", table, colName, key, cellText(rowData[key])))
+		showModal("update", form, form, 64, 7)
+	}
+
+	// compareRow is the row marked with x, waiting for a second row to compare against
+	var compareRow map[string]interface{}
+	compareLabel := ""
+
+	// markOrCompare marks the selected row, or compares it side by side with the marked one
+	markOrCompare := func() {
+		row, _ := resultsTable.GetSelection()
+		i, ok := dataIndex(row)
+		if untransposed != nil || !ok {
+			setStatus("[yellow]Select a result row to compare")
+			return
+		}
+		rowData := currentData[i]
+		label := fmt.Sprintf("Row %d", row)
+		if compareRow == nil || rowHash(compareRow) == rowHash(rowData) {
+			compareRow, compareLabel = rowData, label
+			setStatus("[green]Marked %s for comparison; select another row and press x", label)
+			return
+		}
+		left, leftLabel := compareRow, compareLabel
+		compareRow = nil
+
+		keySet := make(map[string]bool)
+		for k := range left {
+			keySet[k] = true
+		}
+		for k := range rowData {
+			keySet[k] = true
+		}
+		keys := make([]string, 0, len(keySet))
+		for k := range keySet {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		differs := make(map[string]bool)
+		for _, k := range keys {
+			a, inA := left[k]
+			b, inB := rowData[k]
+			if inA != inB || fmt.Sprintf("%v", a) != fmt.Sprintf("%v", b) {
+				differs[k] = true
+			}
+		}
+
+		leftView := tview.NewTextView().SetDynamicColors(true).SetScrollable(true).SetWrap(true)
+		leftView.SetBorder(true).SetTitle(leftLabel + " (marked)")
+		leftView.SetText(detailFields(left, keys, differs))
+		rightView := tview.NewTextView().SetDynamicColors(true).SetScrollable(true).SetWrap(true)
+		rightView.SetBorder(true).SetTitle(label)
+		rightView.SetText(detailFields(rowData, keys, differs))
+		sides := tview.NewFlex().AddItem(leftView, 0, 1, true).AddItem(rightView, 0, 1, false)
+		sides.SetBorder(true).SetTitle(fmt.Sprintf("%d of %d fields differ (Tab switches side, Esc to close)", len(differs), len(keys)))
+		for _, v := range []*tview.TextView{leftView, rightView} {
+			v.SetDoneFunc(func(tcell.Key) {
+				closeModal("compare", resultsTable)
+			})
+			v.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+				if ev.Key() == tcell.KeyTab || ev.Key() == tcell.KeyBacktab {
+					if leftView.HasFocus() {
+						app.SetFocus(rightView)
+					} else {
+						app.SetFocus(leftView)
+					}
+					return nil
+				}
+				return ev
+			})
+		}
+		showModal("compare", sides, leftView, 120, 30)
+	}
+
+	// showSchema pops up the shape of the current results: type, nulls, distinct values and an example per column
+	showSchema := func() {
+		if len(currentData) == 0 {
+			setStatus("[yellow]No results to describe")
+			return
+		}
+		table := tview.NewTable().SetFixed(1, 1).SetSelectable(true, false)
+		for c, h := range []string{"Column", "Type", "Nulls", "Distinct", "Example"} {
+			table.SetCell(0, c, tview.NewTableCell(h).SetTextColor(tcell.ColorYellow).SetAttributes(tcell.AttrBold).SetSelectable(false))
+		}
+		for i, sh := range describeColumns(currentData, currentColumns) {
+			example := sh.Example
+			if len(example) > 40 {
+				example = truncateString(example, 40, cfg.TruncateMode, cfg.EllipsisStr)
+			}
+			table.SetCell(i+1, 0, tview.NewTableCell(sh.Column).SetTextColor(tcell.ColorAqua))
+			table.SetCell(i+1, 1, tview.NewTableCell(sh.Type))
+			table.SetCell(i+1, 2, tview.NewTableCell(strconv.Itoa(sh.Nulls)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 3, tview.NewTableCell(strconv.Itoa(sh.Distinct)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 4, tview.NewTableCell(tview.Escape(example)))
+		}
+		table.SetDoneFunc(func(key tcell.Key) {
+			closeModal("schema", resultsTable)
+		})
+		table.SetBorder(true).SetTitle(fmt.Sprintf("Schema of %d rows (Esc to close)", len(currentData)))
+		showModal("schema", table, table, 90, min(len(currentColumns)+3, 25))
+	}
+
+	// showHistogram charts the distribution of the selected column's values
+	showHistogram := func() {
+		_, col := resultsTable.GetSelection()
+		if untransposed != nil || len(currentData) == 0 || col >= len(currentColumns) {
+			setStatus("[yellow]Select a result column to chart")
+			return
+		}
+		colName := currentColumns[col]
+		bins := columnHistogram(currentData, colName, 15, 10)
+		labelWidth, most := 0, 0
+		for i, b := range bins {
+			bins[i].Label = truncateString(b.Label, 24, cfg.TruncateMode, cfg.EllipsisStr)
+			labelWidth = max(labelWidth, utf8.RuneCountInString(bins[i].Label))
+			most = max(most, b.Count)
+		}
+		const barWidth = 30
+		var text strings.Builder
+		for _, b := range bins {
+			pad := strings.Repeat(" ", labelWidth-utf8.RuneCountInString(b.Label))
+			bar := histogramBar(b.Count, most, barWidth)
+			fmt.Fprintf(&text, "%s%s [green]%s[-]%s %d (%.1f%%)\n", tview.Escape(b.Label), pad, bar,
+				strings.Repeat(" ", barWidth-utf8.RuneCountInString(bar)), b.Count, 100*float64(b.Count)/float64(len(currentData)))
+		}
+		view := tview.NewTextView().SetDynamicColors(true).SetScrollable(true).SetText(text.String())
+		view.SetDoneFunc(func(key tcell.Key) {
+			closeModal("histogram", resultsTable)
+		})
+		view.SetBorder(true).SetTitle(fmt.Sprintf("%s in %d rows (Esc to close)", colName, len(currentData)))
+		showModal("histogram", view, view, labelWidth+barWidth+20, min(len(bins)+2, 25))
+	}
+
+	// showCopyPicker lets the user pick a format and copies the results to the clipboard
+	showCopyPicker := func() {
+		if len(currentData) == 0 {
+			setStatus("[yellow]No results to copy")
+			return
+		}
+		// just the V range when one is selected
+		rows, title := currentData, "Copy results as"
+		if lo, hi, ok := rowRange(); ok {
+			rows, title = currentData[lo-1:hi], fmt.Sprintf("Copy %d rows as", hi-lo+1)
+		}
+		picker := tview.NewList().ShowSecondaryText(false)
+		picker.SetBorder(true).SetTitle(title)
+		for _, f := range exportFormats {
+			format := f
+			picker.AddItem(strings.ToUpper(format[:1])+format[1:], "", 0, func() {
+				closeModal("copy", resultsTable)
+				text, err := serializeRows(format, rows, currentColumns, tableFromQuery(currentQuery, "results"))
+				if err != nil {
+					setStatus("[red]Failed to serialize %s: %v", format, err)
+					return
+				}
+				copyText(text, fmt.Sprintf("%d rows as %s", len(rows), format))
+			})
+		}
+		picker.SetDoneFunc(func() {
+			closeModal("copy", resultsTable)
+		})
+		showModal("copy", picker, picker, 30, len(exportFormats)+2)
+	}
+
+	// showSummaryPicker copies a one-line summary of the results, optionally followed by the query
+	showSummaryPicker := func() {
+		if len(currentColumns) == 0 {
+			setStatus("[yellow]No results to summarize")
+			return
+		}
+		source := "against " + profileName(cfg.Profile)
+		if strings.HasPrefix(resultSource, "snapshot ") || strings.HasPrefix(resultSource, "file ") {
+			source = "from " + resultSource
+		} else if resultSource != "" {
+			source = "against " + resultSource
+		}
+		picker := tview.NewList().ShowSecondaryText(false)
+		picker.SetBorder(true).SetTitle("Copy summary")
+		for _, withQuery := range []bool{false, true} {
+			label, query := "Summary", ""
+			if withQuery {
+				label, query = "Summary with query", currentQuery
+			}
+			picker.AddItem(label, "", 0, func() {
+				closeModal("summary", resultsTable)
+				copyText(resultSummary(currentRowCount, len(currentColumns), resultElapsed, source, query), "result summary")
+			})
+		}
+		picker.SetDoneFunc(func() {
+			closeModal("summary", resultsTable)
+		})
+		showModal("summary", picker, picker, 30, 4)
+	}
+
+	// moveColumn shifts the selected column left (-1) or right (+1) and remembers the order
+	moveColumn := func(delta int) {
+		row, col := resultsTable.GetSelection()
+		target := col + delta
+		if len(currentData) == 0 || col >= len(currentColumns) || target < 0 || target >= len(currentColumns) {
+			return
+		}
+		cols := append([]string(nil), currentColumns...)
+		cols[col], cols[target] = cols[target], cols[col]
+		columnOrders[columnSignature(cols)] = cols
+		// keep the sort indicator on the column that moved
+		switch sortColumn {
+		case col:
+			sortColumn = target
+		case target:
+			sortColumn = col
+		}
+		renderResults()
+		resultsTable.Select(row, target)
+		if err := saveColumnOrders(columnOrders); err != nil {
+			setStatus("[red]Failed to save column order: %v", err)
+		}
+	}
+
+	// showProfilePicker lets the user flip between the results of the last multi-profile run
+	showProfilePicker := func() {
+		if len(profileResults) == 0 {
+			setStatus("[yellow]No multi-profile results (F6 runs the query on all profiles)")
+			return
+		}
+		picker := tview.NewList().ShowSecondaryText(false)
+		picker.SetBorder(true).SetTitle("Profile results")
+		for _, r := range profileResults {
+			r := r
+			label := fmt.Sprintf("%s — %d rows, %dms", r.Name, resultRowCount(r.Res, r.Kind), r.Elapsed.Milliseconds())
+			if r.Err != nil {
+				label = fmt.Sprintf("%s — [red]error[white], %dms", r.Name, r.Elapsed.Milliseconds())
+			}
+			picker.AddItem(label, "", 0, func() {
+				closeModal("profiles", resultsTable)
+				sortColumn = -1
+				sortAscending = true
+				resultSource = r.Name
+				resultElapsed = r.Elapsed
+				showResult(r.Res, r.Kind, r.Raw, r.Err)
+			})
+		}
+		picker.SetDoneFunc(func() {
+			closeModal("profiles", resultsTable)
+		})
+		showModal("profiles", picker, picker, 60, len(profileResults)+2)
+	}
+
+	// snapshots are results frozen with S, newest last; they last for the session
+	var snapshots []snapshot
+	// live holds the results that were shown when a snapshot was opened, so the picker can go
+	// back to them without re-running the query
+	var live snapshot
+	liveSource, liveElapsed := "", time.Duration(0)
+
+	// takeSnapshot asks for a name and freezes the displayed rows (before filters) under it
+	takeSnapshot := func() {
+		if len(allData) == 0 || untransposed != nil {
+			setStatus("[yellow]No results to snapshot")
+			return
+		}
+		input := tview.NewInputField().SetLabel("Name ").SetText(fmt.Sprintf("snapshot %d", len(snapshots)+1)).SetFieldWidth(0)
+		form := tview.NewForm().AddFormItem(input)
+		form.AddButton("Save", func() {
+			name := strings.TrimSpace(input.GetText())
+			if name == "" {
+				return
+			}
+			closeModal("snapshot", resultsTable)
+			snap := snapshot{Name: name, Query: currentQuery, Raw: rawText, Rows: append([]map[string]interface{}(nil), allData...), Taken: time.Now()}
+			// a name that's taken is replaced
+			snapshots = slices.DeleteFunc(snapshots, func(s snapshot) bool { return s.Name == name })
+			snapshots = append(snapshots, snap)
+			setStatus("[green]Saved %d rows as snapshot %q (s to open)", len(snap.Rows), name)
+		})
+		form.AddButton("Cancel", func() {
+			closeModal("snapshot", resultsTable)
+		})
+		form.SetCancelFunc(func() {
+			closeModal("snapshot", resultsTable)
+		})
+		form.SetBorder(true).SetTitle(fmt.Sprintf("Snapshot %d rows", len(allData)))
+		showModal("snapshot", form, form, 50, 7)
+	}
+
+	// showSnapshotPicker shows a snapshot in the results pane, read-only until the next query
+	showSnapshotPicker := func() {
+		if len(snapshots) == 0 {
+			setStatus("[yellow]No snapshots (S saves the current results as one)")
+			return
+		}
+		showingSnapshot := strings.HasPrefix(resultSource, "snapshot ")
+		// show puts rows in the results pane as the result of query from source
+		show := func(query, raw, source string, rows []map[string]interface{}, elapsed time.Duration) {
+			closeModal("snapshots", resultsTable)
+			sortColumn = -1
+			sortAscending = true
+			columnFilters = nil
+			setResultQuery(query)
+			resultSource = source
+			resultElapsed = elapsed
+			showResult(append([]map[string]interface{}(nil), rows...), "json", raw, nil)
+		}
+		picker := tview.NewList().SetSecondaryTextColor(tcell.ColorGray)
+		picker.SetBorder(true).SetTitle("Snapshots")
+		summary := func(query string) string {
+			return tview.Escape(truncateString(strings.Join(strings.Fields(query), " "), 56, "end", cfg.EllipsisStr))
+		}
+		if showingSnapshot && live.Rows != nil {
+			label := fmt.Sprintf("[yellow]Latest results[white] — %d rows", len(live.Rows))
+			picker.AddItem(label, summary(live.Query), 0, func() {
+				show(live.Query, live.Raw, liveSource, live.Rows, liveElapsed)
+			})
+		}
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			snap := snapshots[i]
+			label := fmt.Sprintf("%s — %d rows, %s", tview.Escape(snap.Name), len(snap.Rows), snap.Taken.Format("15:04:05"))
+			picker.AddItem(label, summary(snap.Query), 0, func() {
+				if !strings.HasPrefix(resultSource, "snapshot ") {
+					// keep what's on screen so it can be picked again
+					rows := allData
+					if untransposed != nil {
+						rows = untransposed.all
+					}
+					live = snapshot{Query: currentQuery, Raw: rawText, Rows: slices.Clone(rows)}
+					liveSource, liveElapsed = resultSource, resultElapsed
+				}
+				stopAutoRefresh("a snapshot is shown")
+				show(snap.Query, snap.Raw, "snapshot "+snap.Name, snap.Rows, 0)
+			})
+		}
+		picker.SetDoneFunc(func() {
+			closeModal("snapshots", resultsTable)
+		})
+		showModal("snapshots", picker, picker, 64, min(2*picker.GetItemCount()+2, 20))
+	}
+
+	// runAllProfiles runs the query against every profile and opens the picker when all are done
+	runAllProfiles := func(query string) {
+		query = strings.TrimSpace(query)
+		if query == "" || replayOnly() {
+			return
+		}
+		if len(cfg.Profiles) == 0 {
+			setStatus("[yellow]No profiles configured")
+			return
+		}
+		expanded, err := expandAlias(cfg, query)
+		if err != nil {
+			setStatus("[red]%v", err)
+			return
+		}
+		setStatus("[yellow]Running query on %d profiles...", len(cfg.Profiles))
+		setResultQuery(prepareQuery(cfg, expanded))
+		appendHistory(hist, query, cfg.Profile, cfg.MaxHistoryEntries)
+		if err := saveHistorySoon(); err != nil {
+			setStatus("[red]Failed to save history: %v", err)
+		} else {
+			refreshHistoryList()
+		}
+		c, sent := cfg, prepareQuery(cfg, expanded)
+		go func() {
+			results := fetchAllProfiles(c, sent, func(queued, running, done int) {
+				app.QueueUpdateDraw(func() {
+					setStatus("[yellow]Running on profiles: %d running, %d queued, %d done", running, queued, done)
+				})
+			})
+			app.QueueUpdateDraw(func() {
+				profileResults = results
+				showProfilePicker()
+			})
+		}()
+	}
+
+	// resizeFocused grows (+1) or shrinks (-1) the focused pane and saves the layout
+	resizeFocused := func(delta int) {
+		l := &cfg.Layout
+		switch app.GetFocus() {
+		case historyList:
+			l.HistoryWidth += 2 * delta
+		case editor:
+			l.EditorHeight += delta
+		case resultsTable:
+			l.ResultsWeight += delta
+		case detailView, rawView:
+			l.BottomWeight += delta
+		default:
+			return
+		}
+		cfg.Layout = normalizeLayout(cfg.Layout)
+		applyLayout()
+		if err := saveConfig(cfg); err != nil {
+			setStatus("[red]Failed to save layout: %v", err)
+		}
+	}
+
+	// toggleBookmark marks or unmarks the selected row
+	toggleBookmark := func() {
+		row, col := resultsTable.GetSelection()
+		if row <= 0 || row > len(currentData) {
+			return
+		}
+		id := rowID(row - 1)
+		if bookmarks[id] {
+			delete(bookmarks, id)
+			setStatus("[green]Bookmark removed (%d left)", len(bookmarks))
+		} else {
+			bookmarks[id] = true
+			setStatus("[green]Row %d bookmarked (%d total)", row, len(bookmarks))
+		}
+		renderResults()
+		resultsTable.Select(row, col)
+	}
+
+	// nextBookmark selects the next bookmarked row after the selection, wrapping around
+	nextBookmark := func() {
+		if len(bookmarks) == 0 {
+			setStatus("[yellow]No bookmarks (press m to add one)")
+			return
+		}
+		row, col := resultsTable.GetSelection()
+		for n := 1; n <= len(currentData); n++ {
+			i := (row - 1 + n) % len(currentData)
+			if i < 0 {
+				i += len(currentData)
+			}
+			if bookmarks[rowID(i)] {
+				resultsTable.Select(i+1, col)
+				updateDetailView()
+				return
 			}
-			resultsTable.Select(newRow, col)
-			return nil
-		case tcell.KeyPgUp:
-			// Jump up by configured page step
-			newRow := row - cfg.PageScrollStep
-			if newRow < 1 { // Skip header row (row 0)
-				newRow = 1
+		}
+	}
+
+	// shiftBottomSplit gives the detail view more (+1) or less (-1) of the bottom row
+	shiftBottomSplit := func(delta int) {
+		l := &cfg.Layout
+		l.Collapsed = ""
+		if delta > 0 {
+			if l.RawWeight > 1 {
+				l.RawWeight--
+			} else {
+				l.DetailWeight++
 			}
-			resultsTable.Select(newRow, col)
-			return nil
-		case tcell.KeyDown, tcell.KeyUp:
-			// Detect key repeat: if same key pressed within configured timeout, it's a repeat
-			isRepeat := false
-			if event.Key() == lastKey && now.Sub(lastKeyTime) < time.Duration(cfg.ScrollRepeatTimeoutMs)*time.Millisecond {
-				keyRepeatCount++
-				isRepeat = true
+		} else {
+			if l.DetailWeight > 1 {
+				l.DetailWeight--
 			} else {
-				keyRepeatCount = 0
+				l.RawWeight++
 			}
-			lastKey = event.Key()
-			lastKeyTime = now
-			
-			// Calculate scroll step: start with 1, accelerate after threshold
-			step := 1
-			if isRepeat && keyRepeatCount > cfg.ScrollRepeatThreshold {
-				step = cfg.ScrollAcceleration
+		}
+		applyLayout()
+		if err := saveConfig(cfg); err != nil {
+			setStatus("[red]Failed to save layout: %v", err)
+		}
+	}
+
+	// shiftHistorySplit moves the line between the history list and its preview: up (-1) for a
+	// bigger preview, down (+1) for a longer list. It shows a hidden preview again.
+	shiftHistorySplit := func(delta int) {
+		l := &cfg.Layout
+		l.HidePreview = false
+		if delta > 0 {
+			if l.PreviewWeight > 1 {
+				l.PreviewWeight--
+			} else {
+				l.HistoryListWeight++
 			}
-			
-			var newRow int
-			if event.Key() == tcell.KeyDown {
-				newRow = row + step
-				if newRow >= rowCount {
-					newRow = rowCount - 1
-				}
+		} else {
+			if l.HistoryListWeight > 1 {
+				l.HistoryListWeight--
 			} else {
-				newRow = row - step
-				if newRow < 1 {
-					newRow = 1
+				l.PreviewWeight++
+			}
+		}
+		applyLayout()
+		if err := saveConfig(cfg); err != nil {
+			setStatus("[red]Failed to save layout: %v", err)
+		}
+	}
+
+	// toggleHistoryPreview hides or shows the history preview
+	toggleHistoryPreview := func() {
+		cfg.Layout.HidePreview = !cfg.Layout.HidePreview
+		applyLayout()
+		if err := saveConfig(cfg); err != nil {
+			setStatus("[red]Failed to save layout: %v", err)
+		} else if cfg.Layout.HidePreview {
+			setStatus("[green]History preview hidden (p to show)")
+		} else {
+			setStatus("[green]History preview shown")
+		}
+	}
+
+	// cycleBottomCollapse shows both bottom panes, then only detail, then only raw
+	cycleBottomCollapse := func() {
+		l := &cfg.Layout
+		switch l.Collapsed {
+		case "":
+			l.Collapsed = "raw"
+		case "raw":
+			l.Collapsed = "detail"
+		default:
+			l.Collapsed = ""
+		}
+		// don't leave focus on a hidden pane
+		if (l.Collapsed == "raw" && app.GetFocus() == rawView) || (l.Collapsed == "detail" && app.GetFocus() == detailView) {
+			app.SetFocus(resultsTable)
+			updateFocusColors(resultsTable)
+		}
+		applyLayout()
+		if err := saveConfig(cfg); err != nil {
+			setStatus("[red]Failed to save layout: %v", err)
+		}
+	}
+
+	// offerLiteralEdit offers to swap one literal value of the query just loaded in the editor
+	offerLiteralEdit := func(query string) {
+		literals := findLiterals(query)
+		if len(literals) == 0 {
+			app.SetFocus(editor)
+			updateFocusColors(editor)
+			setStatus("[yellow]No literal values to replace")
+			return
+		}
+		options := make([]string, len(literals))
+		for i, l := range literals {
+			options[i] = query[l[0]:l[1]]
+		}
+		// Park the cursor on the first literal in case the prompt is cancelled
+		editor.Select(literals[0][0], literals[0][1])
+
+		input := tview.NewInputField().SetLabel("Replace with ").SetText(options[0]).SetFieldWidth(40)
+		literal := tview.NewDropDown().SetLabel("Literal      ")
+		literal.SetOptions(options, func(option string, _ int) {
+			input.SetText(option)
+		})
+		literal.SetCurrentOption(0)
+
+		form := tview.NewForm().AddFormItem(literal).AddFormItem(input)
+		form.AddButton("Apply", func() {
+			i, _ := literal.GetCurrentOption()
+			l := literals[i]
+			val := input.GetText()
+			closeModal("params", editor)
+			editor.Replace(l[0], l[1], val)
+			editor.Select(l[0]+len(val), l[0]+len(val))
+			setStatus("[green]Replaced %s with %s (%s to run)", options[i], val, runKey)
+		})
+		form.AddButton("Cancel", func() {
+			closeModal("params", editor)
+		})
+		form.SetCancelFunc(func() {
+			closeModal("params", editor)
+		})
+		form.SetBorder(true).SetTitle("Edit parameter")
+		showModal("params", form, form, 64, 9)
+	}
+
+	// editHistoryEntry loads a history entry into the editor and offers to swap one literal value
+	editHistoryEntry := func(idx int) {
+		if idx < 0 || idx >= len(hist.Entries) {
+			return
+		}
+		query := hist.Entries[idx].Query
+		loadFromHistory(query, func() { offerLiteralEdit(query) })
+	}
+
+	// exportTo writes the results as JSON to path, creating its directory if needed
+	exportTo := func(path string) {
+		var b []byte
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".db", ".sqlite", ".sqlite3":
+			table := strings.Trim(tableFromQuery(currentQuery, "results"), `"`)
+			data, err := encodeSQLite(table, currentData, currentColumns)
+			if err != nil {
+				setStatus("[red]Failed to build SQLite file: %v", err)
+				return
+			}
+			b = data
+		default:
+			text, err := serializeRows("json", currentData, currentColumns, "")
+			if err != nil {
+				setStatus("[red]Failed to marshal JSON: %v", err)
+				return
+			}
+			b = []byte(text)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			setStatus("[red]Failed to create directory: %v", err)
+			return
+		}
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			setStatus("[red]Failed to export: %v", err)
+			return
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		toast("Exported %d rows to\n%s", len(currentData), path)
+	}
+
+	// confirmOverwrite calls onYes straight away for a new path, and for an existing file only
+	// once the user agrees to overwrite it; focus goes back to back either way
+	confirmOverwrite := func(path string, back tview.Primitive, onYes func()) {
+		if _, err := os.Stat(path); err != nil {
+			onYes()
+			return
+		}
+		confirm := tview.NewModal().
+			SetText(fmt.Sprintf("%s already exists. Overwrite it?", path)).
+			AddButtons([]string{"Overwrite", "Cancel"}).
+			SetDoneFunc(func(_ int, label string) {
+				closeModal("overwrite", back)
+				if label == "Overwrite" {
+					onYes()
+				} else {
+					setStatus("[yellow]Kept %s", path)
 				}
+			})
+		showModal("overwrite", confirm, confirm, 60, 7)
+	}
+
+	// showExportPrompt asks where to export the results, confirming before overwriting
+	showExportPrompt := func() {
+		if len(currentData) == 0 {
+			setStatus("[yellow]No results to export")
+			return
+		}
+		back := app.GetFocus()
+		defaultPath := filepath.Join(cfg.ExportDir, fmt.Sprintf("dbx_export_%d.json", time.Now().Unix()))
+		input := tview.NewInputField().SetLabel("Path ").SetText(defaultPath).SetFieldWidth(0)
+		form := tview.NewForm().AddFormItem(input)
+		form.AddButton("Export", func() {
+			path := strings.TrimSpace(input.GetText())
+			if path == "" {
+				return
 			}
-			resultsTable.Select(newRow, col)
-			return nil
+			closeModal("export", back)
+			confirmOverwrite(path, back, func() { exportTo(path) })
+		})
+		form.AddButton("Cancel", func() {
+			closeModal("export", back)
+		})
+		form.SetCancelFunc(func() {
+			closeModal("export", back)
+		})
+		form.SetBorder(true).SetTitle(fmt.Sprintf("Export %d rows (.json, or .db for SQLite)", len(currentData)))
+		showModal("export", form, form, 70, 7)
+	}
+
+	// sqlFilePath is the last .sql file saved or loaded, offered again by the next prompt
+	sqlFilePath := filepath.Join(cfg.ExportDir, "query.sql")
+
+	// writeSQLFile saves the editor query as plain SQL
+	writeSQLFile := func(path string) {
+		q := strings.TrimSpace(editor.GetText())
+		if err := os.WriteFile(path, []byte(q+"\n"), 0644); err != nil {
+			setStatus("[red]Failed to save query: %v", err)
+			return
 		}
-		return event
-	})
+		sqlFilePath = path
+		toast("Saved query to\n%s", path)
+	}
 
-	detailView := tview.NewTextView().SetDynamicColors(true).SetScrollable(true).SetWordWrap(true)
-	detailView.SetBorder(true).SetTitle("Detail")
+	// showSQLFilePrompt asks for a path, then saves the editor query to it or loads it into the editor
+	showSQLFilePrompt := func(save bool) {
+		if save && strings.TrimSpace(editor.GetText()) == "" {
+			setStatus("[yellow]No query to save")
+			return
+		}
+		back := app.GetFocus()
+		input := tview.NewInputField().SetLabel("Path ").SetText(sqlFilePath).SetFieldWidth(0)
+		form := tview.NewForm().AddFormItem(input)
+		title, button := "Load query from file", "Load"
+		if save {
+			title, button = "Save query to file", "Save"
+		}
+		form.AddButton(button, func() {
+			path := strings.TrimSpace(input.GetText())
+			if path == "" {
+				return
+			}
+			closeModal("sql-file", back)
+			if !save {
+				b, err := os.ReadFile(path)
+				if err != nil {
+					setStatus("[red]Failed to load query: %v", err)
+					return
+				}
+				editor.SetText(strings.TrimSpace(string(b)), true)
+				sqlFilePath = path
+				app.SetFocus(editor)
+				updateFocusColors(editor)
+				setStatus("[green]Loaded query from %s", path)
+				return
+			}
+			if filepath.Ext(path) == "" {
+				path += ".sql"
+			}
+			confirmOverwrite(path, back, func() { writeSQLFile(path) })
+		})
+		form.AddButton("Cancel", func() {
+			closeModal("sql-file", back)
+		})
+		form.SetCancelFunc(func() {
+			closeModal("sql-file", back)
+		})
+		form.SetBorder(true).SetTitle(title)
+		showModal("sql-file", form, form, 70, 7)
+	}
 
-	rawView := tview.NewTextView().SetDynamicColors(true).SetScrollable(true)
-	rawView.SetBorder(true).SetTitle("Raw Output")
+	// applyFilters re-derives the displayed rows from the unfiltered result
+	applyFilters := func() {
+		currentData = filterRows(allData, columnFilters, cfg.FilterCaseSensitive)
+		if sortColumn >= 0 && sortColumn < len(currentColumns) {
+			sortRows(currentData, currentColumns[sortColumn], sortAscending)
+		}
+		currentRowCount = len(currentData)
+		renderResults()
+		restoreSelection(-1)
+		updateDetailView()
+	}
 
-	connectionStatus := tview.NewTextView().SetDynamicColors(true)
-	connectionStatus.SetBorder(true).SetTitle("Connection")
-	connectionStatus.SetText("[yellow]●[white] Checking...")
+	// toggleServerOrder puts the rows back in the order the server returned them, undoing a
+	// local sort without re-running the query; pressed again, it re-applies that sort
+	toggleServerOrder := func() {
+		if untransposed != nil || len(allData) == 0 {
+			setStatus("[yellow]No results to reorder")
+			return
+		}
+		if sortColumn >= 0 && sortColumn < len(currentColumns) {
+			unsortedFrom, unsortedAsc = currentColumns[sortColumn], sortAscending
+			sortColumn, sortAscending = -1, true
+			allData = slices.Clone(serverOrder)
+			applyFilters()
+			setStatus("[green]Server order restored (o again to sort by %s)", unsortedFrom)
+			return
+		}
+		col := slices.Index(currentColumns, unsortedFrom)
+		if col < 0 {
+			setStatus("[yellow]Rows are already in server order")
+			return
+		}
+		sortColumn, sortAscending = col, unsortedAsc
+		if len(columnFilters) > 0 {
+			sortRows(allData, unsortedFrom, sortAscending)
+		}
+		applyFilters()
+		setStatus("[green]Sorted by %s again", unsortedFrom)
+	}
 
-	status := tview.NewTextView().SetDynamicColors(true)
-	status.SetBorder(false)
-	
-	// Function to update border colors based on focus
-	updateFocusColors = func(focused tview.Primitive) {
-		// Reset all borders to default color
-		historyList.SetBorderColor(tcell.ColorWhite)
-		editor.SetBorderColor(tcell.ColorWhite)
-		resultsTable.SetBorderColor(tcell.ColorWhite)
-		detailView.SetBorderColor(tcell.ColorWhite)
-		rawView.SetBorderColor(tcell.ColorWhite)
-		
-		// Highlight focused pane with green
-		switch focused {
-		case historyList:
-			historyList.SetBorderColor(tcell.ColorGreen)
-		case editor:
-			editor.SetBorderColor(tcell.ColorGreen)
-		case resultsTable:
-			resultsTable.SetBorderColor(tcell.ColorGreen)
-		case detailView:
-			detailView.SetBorderColor(tcell.ColorGreen)
-		case rawView:
-			rawView.SetBorderColor(tcell.ColorGreen)
+	// toggleGroups groups the results by the selected column's value, or ungroups them
+	toggleGroups := func() {
+		if grouped != nil {
+			grouped = nil
+			renderResults()
+			restoreSelection(-1)
+			updateDetailView()
+			setStatus("[green]Ungrouped results")
+			return
+		}
+		_, col := resultsTable.GetSelection()
+		if untransposed != nil || len(currentData) == 0 || col >= len(currentColumns) {
+			setStatus("[yellow]Select a result column to group by")
+			return
 		}
+		grouped = &groupView{Column: currentColumns[col], Expanded: make(map[string]bool)}
+		renderResults()
+		resultsTable.Select(1, 0)
+		updateDetailView()
+		setStatus("[green]Grouped %d rows into %d groups by %s (Enter expands a group, G ungroups)", len(currentData), len(grouped.Lines), grouped.Column)
 	}
-	
-	// Add mouse click handlers to update focus colors
-	historyList.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
-		if action == tview.MouseLeftClick {
-			updateFocusColors(historyList)
+
+	// toggleTranspose swaps the results for a field-per-row view of the selected row, or of
+	// every row when there are only a few, and back
+	toggleTranspose := func() {
+		if untransposed != nil {
+			v := untransposed
+			untransposed = nil
+			currentData, allData, columnFilters = v.data, v.all, v.filters
+			sortColumn, sortAscending = v.sortCol, v.sortAsc
+			currentRowCount = len(currentData)
+			renderResults()
+			restoreSelection(-1)
+			updateDetailView()
+			return
 		}
-		return action, event
-	})
-	editor.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
-		if action == tview.MouseLeftClick {
-			updateFocusColors(editor)
+		row, _ := resultsTable.GetSelection()
+		if len(currentData) == 0 {
+			setStatus("[yellow]No results to transpose")
+			return
 		}
-		return action, event
-	})
-	resultsTable.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
-		if action == tview.MouseLeftClick {
-			updateFocusColors(resultsTable)
+		if len(currentData) <= cfg.TransposeMaxRows {
+			showTransposed(currentData, "")
+			return
 		}
-		return action, event
-	})
-	detailView.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
-		if action == tview.MouseLeftClick {
-			updateFocusColors(detailView)
+		if row <= 0 || row > len(currentData) {
+			setStatus("[yellow]No row selected")
+			return
 		}
-		return action, event
-	})
-	rawView.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
-		if action == tview.MouseLeftClick {
-			updateFocusColors(rawView)
+		showTransposed(currentData[row-1:row], rowID(row-1))
+	}
+
+	// showJumpPrompt asks for a row number and selects that row
+	showJumpPrompt := func() {
+		if len(currentData) == 0 {
+			setStatus("[yellow]No rows to jump to")
+			return
 		}
-		return action, event
-	})
+		input := tview.NewInputField().SetLabel("Row ").SetFieldWidth(0).SetAcceptanceFunc(tview.InputFieldInteger)
+		input.SetDoneFunc(func(key tcell.Key) {
+			closeModal("jump", resultsTable)
+			n, err := strconv.Atoi(input.GetText())
+			if key != tcell.KeyEnter || err != nil {
+				return
+			}
+			n = min(max(n, 1), len(currentData))
+			_, col := resultsTable.GetSelection()
+			resultsTable.Select(n, col)
+			updateDetailView()
+		})
+		input.SetBorder(true).SetTitle(fmt.Sprintf("Jump to row (1-%d)", len(currentData)))
+		showModal("jump", input, input, 40, 3)
+	}
 
-	// layout
-	flex := tview.NewFlex().SetDirection(tview.FlexRow)
-	
-	// Top bar with connection status
-	topBar := tview.NewFlex()
-	topBar.AddItem(connectionStatus, 20, 0, false)
-	
-	flex.AddItem(topBar, 3, 0, false)
-	
-	top := tview.NewFlex()
-	
-	// History column with list and preview
-	historyColumn := tview.NewFlex().SetDirection(tview.FlexRow)
-	historyColumn.AddItem(historyList, 0, 2, false)
-	historyColumn.AddItem(historyPreview, 0, 1, false)
-	
-	top.AddItem(historyColumn, 30, 1, false)
+	// showFilterPrompt asks for a filter on the selected column; an empty value removes it
+	showFilterPrompt := func() {
+		_, col := resultsTable.GetSelection()
+		if len(allData) == 0 || col >= len(currentColumns) {
+			setStatus("[yellow]No column to filter")
+			return
+		}
+		column := currentColumns[col]
+		existing := ""
+		for _, f := range columnFilters {
+			if f.Column == column {
+				existing = f.Op + " " + f.Value
+			}
+		}
+		input := tview.NewInputField().SetLabel(column + " ").SetText(existing).SetFieldWidth(0)
+		input.SetDoneFunc(func(key tcell.Key) {
+			closeModal("filter", resultsTable)
+			if key != tcell.KeyEnter {
+				return
+			}
+			var kept []columnFilter
+			for _, f := range columnFilters {
+				if f.Column != column {
+					kept = append(kept, f)
+				}
+			}
+			if expr := strings.TrimSpace(input.GetText()); expr != "" {
+				kept = append(kept, parseColumnFilter(column, expr))
+			}
+			columnFilters = kept
+			applyFilters()
+			setStatus("[green]%d of %d rows match %d filter(s)", len(currentData), len(allData), len(columnFilters))
+		})
+		mode := "ignoring case"
+		if cfg.FilterCaseSensitive {
+			mode = "case-sensitive"
+		}
+		input.SetBorder(true).SetTitle(fmt.Sprintf("Filter (=, !=, contains, >, >=, <, <=; empty removes; %s)", mode))
+		showModal("filter", input, input, 60, 3)
+	}
 
-	bottomRow := tview.NewFlex()
-	bottomRow.AddItem(detailView, 0, 1, true)
-	bottomRow.AddItem(rawView, 0, 1, true)
+	// findMatch moves the selection to the next (dir 1) or previous (dir -1) row matching the
+	// search, wrapping around
+	findMatch := func(dir int) {
+		if searchTerm == "" {
+			setStatus("[yellow]No search; press / to search")
+			return
+		}
+		row, col := resultsTable.GetSelection()
+		n := len(currentData)
+		for step := 1; step <= n; step++ {
+			i := ((row-1+dir*step)%n + n) % n
+			hits := searchColumns(currentData[i], currentColumns, searchTerm, cfg.FilterCaseSensitive)
+			if len(hits) == 0 {
+				continue
+			}
+			if !slices.Contains(hits, col) {
+				col = hits[0]
+			}
+			resultsTable.Select(i+1, col)
+			updateDetailView()
+			setStatus("[green]Row %d matches /%s", i+1, tview.Escape(searchTerm))
+			return
+		}
+		setStatus("[yellow]No rows match /%s", tview.Escape(searchTerm))
+	}
 
-	center := tview.NewFlex().SetDirection(tview.FlexRow)
-	center.AddItem(editor, 5, 0, true)
-	center.AddItem(resultsTable, 0, 2, false)
-	center.AddItem(bottomRow, 0, 1, true)
+	// showSearchPrompt asks for text to highlight in the results without hiding other rows
+	showSearchPrompt := func() {
+		if len(currentData) == 0 {
+			setStatus("[yellow]No results to search")
+			return
+		}
+		input := tview.NewInputField().SetText(searchTerm).SetFieldWidth(0)
+		input.SetDoneFunc(func(key tcell.Key) {
+			closeModal("search", resultsTable)
+			if key != tcell.KeyEnter {
+				return
+			}
+			searchTerm = strings.TrimSpace(input.GetText())
+			renderResults()
+			if searchTerm == "" {
+				setStatus("[green]Search cleared")
+				return
+			}
+			// land on the first match at or after the cursor
+			row, col := resultsTable.GetSelection()
+			resultsTable.Select(max(row-1, 0), col)
+			findMatch(1)
+		})
+		mode := "ignoring case"
+		if cfg.FilterCaseSensitive {
+			mode = "case-sensitive"
+		}
+		input.SetBorder(true).SetTitle(fmt.Sprintf("Search (n/N next/previous; empty clears; %s)", mode))
+		showModal("search", input, input, 60, 3)
+	}
 
-	top.AddItem(center, 0, 3, true)
+	// showErrors lists recent query errors, newest first; Enter loads the failed query into the editor
+	showErrors := func() {
+		if len(recentErrors) == 0 {
+			setStatus("[green]No errors")
+			return
+		}
+		back := app.GetFocus()
+		list := tview.NewList()
+		list.SetBorder(true).SetTitle(fmt.Sprintf("Recent errors (%d)", len(recentErrors)))
+		for _, e := range recentErrors {
+			e := e
+			code := "no response"
+			if e.Code > 0 {
+				code = fmt.Sprintf("HTTP %d", e.Code)
+			}
+			msg := strings.Join(strings.Fields(e.Message), " ")
+			main := fmt.Sprintf("[red]%s[white] %s — %s", e.Time.Format("15:04:05"), code, tview.Escape(truncateString(msg, 80, "end", cfg.EllipsisStr)))
+			list.AddItem(main, "  "+tview.Escape(strings.Join(strings.Fields(e.Query), " ")), 0, func() {
+				closeModal("errors", editor)
+				editor.SetText(e.Query, true)
+			})
+		}
+		list.SetDoneFunc(func() {
+			closeModal("errors", back)
+		})
+		showModal("errors", list, list, 100, min(2*len(recentErrors)+2, 22))
+	}
 
-	flex.AddItem(top, 0, 1, true)
-	flex.AddItem(status, 1, 0, false)
+	// stepWindow pages the displayed query: pages moves the OFFSET by whole pages, scale
+	// multiplies the LIMIT. Queries without a LIMIT start at limit 100.
+	stepWindow := func(pages int, scale float64) {
+		if currentQuery == "" || isMutatingQuery(currentQuery) {
+			setStatus("[yellow]Paging needs a SELECT query")
+			return
+		}
+		limit, offset, ok := queryWindow(currentQuery)
+		if !ok {
+			// the first step just adds a LIMIT to the unpaged query
+			limit, pages, scale = 100, 0, 1
+		}
+		limit = max(int(float64(limit)*scale), 1)
+		offset = max(offset+pages*limit, 0)
+		q := setQueryWindow(currentQuery, limit, offset)
+		editor.SetText(q, true)
+		runQuery(q, false)
+	}
 
-	// history loading
-	hist, err := loadHistory()
-	if err != nil {
-		// ignore errors but show in status
-		status.SetText(fmt.Sprintf("[red]Failed to load history: %v", err))
-		hist = &History{Entries: []HistoryEntry{}}
+	// toggleAutoRefresh re-runs the displayed query every auto_refresh_sec seconds, counting
+	// down in the results title, or stops doing so
+	toggleAutoRefresh := func() {
+		if stopRefresh != nil {
+			stopAutoRefresh("")
+			setStatus("[green]Auto-refresh off")
+			return
+		}
+		switch {
+		case cfg.AutoRefreshSec <= 0:
+			setStatus("[yellow]Set auto_refresh_sec in the config to use auto-refresh")
+			return
+		case currentQuery == "":
+			setStatus("[yellow]No query run yet")
+			return
+		case isMutatingQuery(currentQuery):
+			setStatus("[yellow]Auto-refresh only re-runs read-only queries")
+			return
+		}
+		stop := make(chan struct{})
+		stopRefresh, refreshPending = stop, false
+		refreshAt = time.Now().Add(time.Duration(cfg.AutoRefreshSec) * time.Second)
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+				}
+				app.QueueUpdateDraw(func() {
+					if stopRefresh != stop || refreshPending {
+						return
+					}
+					if time.Now().Before(refreshAt) {
+						updateResultsTitle()
+						return
+					}
+					refreshPending, refreshRun = true, true
+					runQuery(currentQuery, true)
+					updateResultsTitle()
+				})
+			}
+		}()
+		updateResultsTitle()
+		setStatus("[green]Auto-refresh every %ds (R to stop)", cfg.AutoRefreshSec)
 	}
 
-	refreshHistoryList := func() {
-		historyList.Clear()
-		for i, e := range hist.Entries {
-			label := fmt.Sprintf("%s — %s", e.Timestamp.Format("2006-01-02 15:04:05"), e.Query)
-			// capture index
-			idx := i
-			historyList.AddItem(label, "", 0, func() {
-				editor.SetText(hist.Entries[idx].Query, true)
-				app.SetFocus(editor)
-				updateFocusColors(editor)
-			})
-			if i >= 100 {
-				break
+	// confirmRun runs a query, asking first when the linter has warnings about it or when it
+	// may modify data on a production host
+	confirmRun := func(query string, refresh bool) {
+		if replayOnly() {
+			return
+		}
+		expanded, err := expandAlias(cfg, query)
+		back := app.GetFocus()
+		// the startup run may go through a confirmation first
+		startup := startupRun
+		startupRun = false
+		run := func() {
+			if err != nil || !isDangerousHost(cfg, apiBase) || !isMutatingQuery(expanded) {
+				startupRun = startup
+				runQuery(query, refresh)
+				return
 			}
+			confirm := tview.NewModal().
+				SetText(fmt.Sprintf("⚠ %s is a production host.\n\nRun this data-modifying query?", endpointURL(apiBase))).
+				AddButtons([]string{"Cancel", "Run"}).
+				SetDoneFunc(func(_ int, label string) {
+					closeModal("confirm-run", back)
+					if label == "Run" {
+						startupRun = startup
+						runQuery(query, refresh)
+					} else {
+						setStatus("[yellow]Query cancelled")
+					}
+				})
+			confirm.SetBackgroundColor(tcell.ColorDarkRed)
+			pages.AddPage("confirm-run", confirm, true, true)
+			app.SetFocus(confirm)
+		}
+		var warnings []string
+		if err == nil {
+			warnings = lintQuery(cfg, prepareQuery(cfg, expanded))
+		}
+		if len(warnings) == 0 {
+			run()
+			return
 		}
+		lint := tview.NewModal().
+			SetText("⚠ " + strings.Join(warnings, "\n⚠ ") + "\n\nRun it anyway?").
+			AddButtons([]string{"Cancel", "Run anyway"}).
+			SetDoneFunc(func(_ int, label string) {
+				closeModal("lint", back)
+				if label == "Run anyway" {
+					run()
+				} else {
+					setStatus("[yellow]Query cancelled")
+				}
+			})
+		pages.AddPage("lint", lint, true, true)
+		app.SetFocus(lint)
 	}
 
-	// Update history preview when selection changes
-	historyList.SetChangedFunc(func(index int, mainText string, secondaryText string, shortcut rune) {
-		if index >= 0 && index < len(hist.Entries) {
-			entry := hist.Entries[index]
-			var preview strings.Builder
-			preview.WriteString(fmt.Sprintf("[yellow]Time:[white] %s\n\n", entry.Timestamp.Format("2006-01-02 15:04:05")))
-			preview.WriteString("[yellow]Query:[white]\n")
-			preview.WriteString(entry.Query)
-			historyPreview.SetText(preview.String())
-			historyPreview.ScrollToBeginning()
-		} else {
-			historyPreview.SetText("[gray]No history selected")
+	// keybindings
+	app.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		// While a modal is open it handles all keys itself
+		if pages.GetPageCount() > 1 {
+			return ev
 		}
-	})
 
-	refreshHistoryList()
+		// Ctrl-E to export results
+		if ev.Modifiers() == tcell.ModCtrl && ev.Rune() == 'e' {
+			showExportPrompt()
+			return nil
+		}
 
-	// Show first history item in preview if available
-	if len(hist.Entries) > 0 {
-		var preview strings.Builder
-		preview.WriteString(fmt.Sprintf("[yellow]Time:[white] %s\n\n", hist.Entries[0].Timestamp.Format("2006-01-02 15:04:05")))
-		preview.WriteString("[yellow]Query:[white]\n")
-		preview.WriteString(hist.Entries[0].Query)
-		historyPreview.SetText(preview.String())
-	} else {
-		historyPreview.SetText("[gray]No history available")
-	}
+		// Ctrl-O to open the query URL in the browser
+		if ev.Modifiers() == tcell.ModCtrl && ev.Rune() == 'o' {
+			q := strings.TrimSpace(editor.GetText())
+			if q == "" {
+				setStatus("[yellow]No query to open")
+				return nil
+			}
+			if strings.EqualFold(cfg.Method, "POST") {
+				setStatus("[yellow]Can't open POST queries in a browser (use --print-url)")
+				return nil
+			}
+			u := withParams(cfg, queryURL(routeAPI(cfg, apiBase, q), q))
+			if err := openInBrowser(u); err != nil {
+				setStatus("[red]Failed to open browser: %v", err)
+			} else {
+				setStatus("[green]Opened %s", maskURL(u))
+			}
+			return nil
+		}
 
-	// helper to set status message
-	setStatus := func(format string, a ...interface{}) {
-		status.SetText(fmt.Sprintf(format, a...))
-	}
+		// Shift-Up/Down in results to select a range of rows, starting one at the cursor if needed
+		if app.GetFocus() == resultsTable && (ev.Key() == tcell.KeyUp || ev.Key() == tcell.KeyDown) && ev.Modifiers()&tcell.ModShift != 0 {
+			row, col := resultsTable.GetSelection()
+			if grouped != nil || untransposed != nil || len(currentData) == 0 {
+				return nil
+			}
+			if rangeAnchor == 0 {
+				rangeAnchor = row
+			}
+			if ev.Key() == tcell.KeyUp {
+				row = max(row-1, 1)
+			} else {
+				row = min(row+1, len(currentData))
+			}
+			resultsTable.Select(row, col)
+			return nil
+		}
 
-	// Add input handler for history list to delete entries with 'd'
-	historyList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Rune() == 'd' || event.Rune() == 'D' {
-			currentItem := historyList.GetCurrentItem()
-			if currentItem >= 0 && currentItem < len(hist.Entries) {
-				// Remove the entry from history
-				hist.Entries = append(hist.Entries[:currentItem], hist.Entries[currentItem+1:]...)
-				// Save updated history
-				if err := saveHistory(hist); err != nil {
-					setStatus("[red]Failed to save history: %v", err)
+		// Results keys: b/B capture/clear the diff baseline (keyed by the selected column), v views the full cell, y copies results (or the V range), V starts/clears a row range, R toggles auto-refresh, S/s save/open snapshots, Y copies the visible table as TSV, I copies the column as an IN clause, Q copies a result summary, o toggles server row order, K toggles the card view, U writes an UPDATE for the cell, C copies the last query as curl, p picks a profile result, a toggles the aggregate footer, m/' bookmark rows, </> move columns, f/F filter columns, g jumps to a row, G groups by a column, / searches and n/N jump between matches, x marks/compares rows, c toggles filter case, t transposes, P pins columns, [/] and {/} page, i shows the schema, H charts the column's distribution
+		if app.GetFocus() == resultsTable && ev.Key() == tcell.KeyRune {
+			// the grouped view shows lines rather than result rows, so row actions wait until it's left
+			if grouped != nil && ev.Rune() != 'G' {
+				setStatus("[yellow]Press G to ungroup first")
+				return nil
+			}
+			switch ev.Rune() {
+			case 'G':
+				toggleGroups()
+				return nil
+			case '/':
+				showSearchPrompt()
+				return nil
+			case 'n':
+				findMatch(1)
+				return nil
+			case 'N':
+				findMatch(-1)
+				return nil
+			case 'b':
+				_, col := resultsTable.GetSelection()
+				if len(currentData) == 0 || col >= len(currentColumns) {
+					setStatus("[yellow]No results to use as baseline")
+					return nil
+				}
+				baseline = append([]map[string]interface{}(nil), currentData...)
+				baselineKey = currentColumns[col]
+				renderResults()
+				setStatus("[green]Baseline captured: %d rows keyed by %s", len(baseline), baselineKey)
+				return nil
+			case 'B':
+				baseline = nil
+				baselineKey = ""
+				renderResults()
+				setStatus("[green]Baseline cleared")
+				return nil
+			case 'v':
+				showCellValue()
+				return nil
+			case 'y':
+				showCopyPicker()
+				return nil
+			case 'R':
+				toggleAutoRefresh()
+				return nil
+			case 'S':
+				takeSnapshot()
+				return nil
+			case 's':
+				showSnapshotPicker()
+				return nil
+			case 'V':
+				row, _ := resultsTable.GetSelection()
+				if rangeAnchor != 0 {
+					rangeAnchor = 0
+					paintRange()
+					setStatus("[green]Row range cleared")
+				} else if row > 0 && row <= len(currentData) && untransposed == nil {
+					rangeAnchor = row
+					paintRange()
+					setStatus("[green]Range started at row %d: move to extend, y to copy, V to clear", row)
+				}
+				return nil
+			case 'Y':
+				// the table as shown: filtered, sorted and in on-screen column order
+				if len(currentData) == 0 {
+					setStatus("[yellow]No results to copy")
+					return nil
+				}
+				text, _ := serializeRows("tsv", currentData, currentColumns, "")
+				copyText(text, fmt.Sprintf("%d rows × %d columns as TSV", len(currentData), len(currentColumns)))
+				return nil
+			case 'Q':
+				showSummaryPicker()
+				return nil
+			case 'K':
+				toggleCards()
+				return nil
+			case 'o':
+				toggleServerOrder()
+				return nil
+			case 'U':
+				editCellAsUpdate()
+				return nil
+			case 'I':
+				_, col := resultsTable.GetSelection()
+				if len(currentData) == 0 || col >= len(currentColumns) {
+					setStatus("[yellow]No column selected")
+					return nil
+				}
+				clause, n := inClause(currentColumns[col], currentData)
+				if n == 0 {
+					setStatus("[yellow]%s has only nulls", currentColumns[col])
+					return nil
+				}
+				copyText(clause, fmt.Sprintf("%s IN clause (%d distinct values)", currentColumns[col], n))
+				return nil
+			case 'C':
+				if currentQuery == "" {
+					setStatus("[yellow]No query run yet")
+					return nil
+				}
+				cmd, err := curlCommand(cfg, apiBase, currentQuery)
+				if err != nil {
+					setStatus("[red]Failed to build curl command: %v", err)
+					return nil
+				}
+				copyText(cmd, "curl command for the last query")
+				return nil
+			case 'p':
+				showProfilePicker()
+				return nil
+			case 'x':
+				markOrCompare()
+				return nil
+			case 'a':
+				showFooter = !showFooter
+				renderResults()
+				return nil
+			case 'm':
+				toggleBookmark()
+				return nil
+			case '\'':
+				nextBookmark()
+				return nil
+			case '<':
+				moveColumn(-1)
+				return nil
+			case '>':
+				moveColumn(1)
+				return nil
+			case 'i':
+				showSchema()
+				return nil
+			case 'H':
+				showHistogram()
+				return nil
+			case 'f':
+				showFilterPrompt()
+				return nil
+			case 'g':
+				showJumpPrompt()
+				return nil
+			case 't':
+				toggleTranspose()
+				return nil
+			case ']':
+				stepWindow(1, 1)
+				return nil
+			case '[':
+				stepWindow(-1, 1)
+				return nil
+			case '}':
+				stepWindow(0, 2)
+				return nil
+			case '{':
+				stepWindow(0, 0.5)
+				return nil
+			case 'P':
+				// Pin every column up to the selected one; again on the last pinned column unpins
+				_, col := resultsTable.GetSelection()
+				if pinnedColumns == col+1 {
+					pinnedColumns = 0
 				} else {
-					// Refresh the list
-					refreshHistoryList()
-					// Try to select the same position or the last item
-					newCount := historyList.GetItemCount()
-					if newCount > 0 {
-						if currentItem >= newCount {
-							historyList.SetCurrentItem(newCount - 1)
-						} else {
-							historyList.SetCurrentItem(currentItem)
-						}
-					} else {
-						historyPreview.SetText("[gray]No history")
-					}
-					setStatus("[green]History entry deleted")
+					pinnedColumns = col + 1
+				}
+				resultsTable.SetFixed(1, pinnedColumns)
+				updateResultsTitle()
+				return nil
+			case 'c':
+				cfg.FilterCaseSensitive = !cfg.FilterCaseSensitive
+				applyFilters()
+				if err := saveConfig(cfg); err != nil {
+					setStatus("[red]Failed to save config: %v", err)
+				} else if cfg.FilterCaseSensitive {
+					setStatus("[green]Filters are case-sensitive")
+				} else {
+					setStatus("[green]Filters ignore case")
 				}
+				return nil
+			case 'F':
+				if len(columnFilters) > 0 {
+					columnFilters = nil
+					applyFilters()
+					setStatus("[green]Column filters cleared")
+				}
+				return nil
 			}
-			return nil
 		}
-		return event
-	})
 
-	currentRowCount := 0
-	var currentData []map[string]interface{}
-	var currentColumns []string
-	sortColumn := -1
-	sortAscending := true
+		// e in history to load an entry and edit one of its literal values
+		if app.GetFocus() == historyList && ev.Key() == tcell.KeyRune && (ev.Rune() == 'e' || ev.Rune() == 'E') {
+			editHistoryEntry(entryAt(historyList.GetCurrentItem()))
+			return nil
+		}
 
-	// Function to update detail view based on selected row
-	updateDetailView := func() {
-		row, _ := resultsTable.GetSelection()
-		if row <= 0 || row > len(currentData) {
-			detailView.SetText("[yellow]No row selected")
-			return
+		// p in history to hide or show the preview
+		if app.GetFocus() == historyList && ev.Key() == tcell.KeyRune && ev.Rune() == 'p' {
+			toggleHistoryPreview()
+			return nil
 		}
-		rowData := currentData[row-1]
-		var details strings.Builder
-		details.WriteString(fmt.Sprintf("[yellow::b]Row %d/%d[white]\n", row, len(currentData)))
-		
-		// Get keys in sorted order for consistent display
-		keys := make([]string, 0, len(rowData))
-		for k := range rowData {
-			keys = append(keys, k)
+
+		// A in history to switch between the active profile's history and every profile's
+		if app.GetFocus() == historyList && ev.Key() == tcell.KeyRune && ev.Rune() == 'A' {
+			cfg.HistoryAllProfiles = !cfg.HistoryAllProfiles
+			refreshHistoryList()
+			resetHistoryPreview()
+			if err := saveConfig(cfg); err != nil {
+				setStatus("[red]Failed to save config: %v", err)
+			} else if cfg.HistoryAllProfiles {
+				setStatus("[green]History shows every profile's queries")
+			} else {
+				setStatus("[green]History shows the %s profile's queries only", profileName(cfg.Profile))
+			}
+			return nil
 		}
-		sort.Strings(keys)
-		
-		for _, k := range keys {
-			v := rowData[k]
-			valStr := fmt.Sprintf("%v", v)
-			// Compact display: field: value
-			if len(valStr) > 200 {
-				valStr = valStr[:200] + "…"
+
+		// T in results/detail to switch the detail view between a field list and a field | value table
+		if (app.GetFocus() == resultsTable || app.GetFocus() == detailView) && ev.Key() == tcell.KeyRune && ev.Rune() == 'T' {
+			cfg.DetailTable = !cfg.DetailTable
+			updateDetailView()
+			if err := saveConfig(cfg); err != nil {
+				setStatus("[red]Failed to save config: %v", err)
+			} else if cfg.DetailTable {
+				setStatus("[green]Detail shows a field | value table")
+			} else {
+				setStatus("[green]Detail shows a field list")
 			}
-			details.WriteString(fmt.Sprintf("[yellow]%s:[white] %s\n", k, valStr))
+			return nil
 		}
-		detailView.SetText(details.String())
-		detailView.ScrollToBeginning()
-	}
 
-	// Setup selection changed handler for results table
-	resultsTable.SetSelectionChangedFunc(func(row, col int) {
-		if row > 0 && len(currentData) > 0 {
+		// J in results/detail to copy the path of a field inside the selected JSON cell
+		if (app.GetFocus() == resultsTable || app.GetFocus() == detailView) && ev.Key() == tcell.KeyRune && ev.Rune() == 'J' && grouped == nil {
+			showJSONPaths()
+			return nil
+		}
+
+		// d in results/detail to decode the selected column's base64 value
+		if (app.GetFocus() == resultsTable || app.GetFocus() == detailView) && ev.Key() == tcell.KeyRune && ev.Rune() == 'd' && grouped == nil {
+			showBlob()
+			return nil
+		}
+
+		// z in results/detail to collapse or expand JSON fields in the detail view
+		if (app.GetFocus() == resultsTable || app.GetFocus() == detailView) && ev.Key() == tcell.KeyRune && ev.Rune() == 'z' {
+			jsonCollapsed = !jsonCollapsed
 			updateDetailView()
+			return nil
 		}
-	})
 
-	// Setup click handler for column sorting
-	resultsTable.SetSelectedFunc(func(row, col int) {
-		if row == 0 && len(currentData) > 0 && col < len(currentColumns) {
-			// Clicked on header - sort by this column
-			colName := currentColumns[col]
-			
-			// Toggle sort direction if same column
-			if sortColumn == col {
-				sortAscending = !sortAscending
+		// r in the raw view to switch between pretty-printed JSON and the exact response bytes
+		if app.GetFocus() == rawView && ev.Key() == tcell.KeyRune && ev.Rune() == 'r' {
+			rawPretty = !rawPretty
+			showRaw()
+			return nil
+		}
+
+		// e in results/detail to show the selected column's value in full in the detail view
+		if (app.GetFocus() == resultsTable || app.GetFocus() == detailView) && ev.Key() == tcell.KeyRune && ev.Rune() == 'e' {
+			row, col := resultsTable.GetSelection()
+			if row <= 0 || row > len(currentData) || col >= len(currentColumns) {
+				return nil
+			}
+			if field := currentColumns[col]; expandedField == field && expandedRow == row {
+				expandedField = ""
 			} else {
-				sortColumn = col
-				sortAscending = true
+				expandedField, expandedRow = field, row
 			}
-			
-			// Sort the data
-			sort.Slice(currentData, func(i, j int) bool {
-				vi := fmt.Sprintf("%v", currentData[i][colName])
-				vj := fmt.Sprintf("%v", currentData[j][colName])
-				if sortAscending {
-					return vi < vj
-				}
-				return vi > vj
-			})
-			
-			// Re-render table
-			renderJSONToTable(currentData, resultsTable, &currentColumns, cfg)
-			resultsTable.SetTitle(fmt.Sprintf("Results (%d rows) [sorted by %s %s]", len(currentData), colName, map[bool]string{true: "↑", false: "↓"}[sortAscending]))
-			resultsTable.Select(1, 0)
 			updateDetailView()
+			return nil
 		}
-	})
-
-	runQuery := func(query string) {
-		setStatus("[yellow]Running query...")
-		sortColumn = -1 // Reset sorting
-		sortAscending = true
 
-		// Auto-save to history
-		appendHistory(hist, query, cfg.MaxHistoryEntries)
-		if err := saveHistory(hist); err != nil {
-			setStatus("[red]Failed to save history: %v", err)
-		} else {
-			refreshHistoryList()
+		// F7 to list recent errors
+		if ev.Key() == tcell.KeyF7 {
+			showErrors()
+			return nil
 		}
 
-		// Focus results table immediately
-		app.SetFocus(resultsTable)
-		currentRowCount = 0
-		resultsTable.Clear()
+		// F6 to run the editor query against all profiles
+		if ev.Key() == tcell.KeyF6 {
+			runAllProfiles(editor.GetText())
+			return nil
+		}
 
-		go func() {
-			res, kind, raw, err := fetchQuery(defaultAPI, query)
-			
-			app.QueueUpdateDraw(func() {
-				if err != nil {
-					setStatus("[red]Error: %v", err)
-					rawView.SetText(fmt.Sprintf("Error: %v", err))
-					rawView.ScrollToBeginning()
-					return
+		// F11 to zoom the focused pane to the whole screen, or restore the normal layout
+		if ev.Key() == tcell.KeyF11 {
+			if zoomed != nil {
+				zoomed = nil
+				setStatus("[green]Restored the normal layout")
+			} else {
+				switch f := app.GetFocus(); f {
+				case historyList, editor, resultsTable, detailView, rawView:
+					zoomed = f
+					setStatus("[green]Zoomed the focused pane (F11 to restore)")
+				default:
+					return nil
 				}
-				
-				// Always show raw output
-				rawView.SetText(raw)
-				rawView.ScrollToBeginning()
-
-				if kind == "json" {
-					// try cast to []map[string]interface{}
-					switch v := res.(type) {
-				case []map[string]interface{}:
-					currentData = v
-					renderJSONToTable(v, resultsTable, &currentColumns, cfg)
-					currentRowCount = len(v)
-					resultsTable.SetTitle(fmt.Sprintf("Results (%d rows)", currentRowCount))
-					if currentRowCount > 0 {
-						resultsTable.Select(1, 0)
-						updateDetailView()
-					} else {
-						detailView.SetText("[yellow]No results")
-					}
-					setStatus("[green]Fetched %d rows", len(v))
-					return
-					case []interface{}:
-						// try convert items to map
-						maps := make([]map[string]interface{}, 0, len(v))
-						for _, item := range v {
-							if m, ok := item.(map[string]interface{}); ok {
-								maps = append(maps, m)
-							} else {
-								// not uniform, table might be empty or partial
-							}
-						}
-					if len(maps) > 0 {
-						currentData = maps
-						renderJSONToTable(maps, resultsTable, &currentColumns, cfg)
-						currentRowCount = len(maps)
-						resultsTable.SetTitle(fmt.Sprintf("Results (%d rows)", currentRowCount))
-						resultsTable.Select(1, 0)
-						updateDetailView()
-						setStatus("[green]Fetched %d rows", len(maps))
-					} else {
-						resultsTable.Clear()
-						currentData = nil
-						currentRowCount = 0
-						detailView.SetText("[yellow]JSON result (non-tabular)")
-						setStatus("[green]JSON result (non-tabular)")
-					}
-						return
-					default:
-						resultsTable.Clear()
-						currentData = nil
-						currentRowCount = 0
-						detailView.SetText("[yellow]JSON result (see raw output)")
-						setStatus("[green]JSON result")
-						return
-					}
 			}
-			// text
-			resultsTable.Clear()
-			currentData = nil
-			currentRowCount = 0
-			detailView.SetText("[yellow]Text result (see raw output)")
-			setStatus("[green]Text result")
-		})
-	}()
-	}
+			applyLayout()
+			return nil
+		}
 
-	// Connection status checker
-	go func() {
-		for {
-			resp, err := http.Get(defaultAPI[:len(defaultAPI)-3]) // Remove "?q=" suffix
-			app.QueueUpdateDraw(func() {
-				if err == nil && resp != nil {
-					resp.Body.Close()
-					if resp.StatusCode < 500 {
-						connectionStatus.SetText("[green]●[white] Connected")
-					} else {
-						connectionStatus.SetText("[yellow]●[white] Server Error")
-					}
-				} else {
-					connectionStatus.SetText("[red]●[white] Disconnected")
-				}
-			})
-			time.Sleep(time.Duration(cfg.ConnectionCheckSec) * time.Second)
+		// Esc to stop a streaming query
+		if ev.Key() == tcell.KeyEscape && stopStream != nil {
+			stopStream()
+			setStatus("[yellow]Stopping stream...")
+			return nil
 		}
-	}()
 
-	// keybindings
-	app.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
-		// Ctrl-E to export results
-		if ev.Modifiers() == tcell.ModCtrl && ev.Rune() == 'e' {
-			if len(currentData) > 0 {
-				b, err := json.MarshalIndent(currentData, "", "  ")
-				if err == nil {
-					filename := fmt.Sprintf("dbx_export_%d.json", time.Now().Unix())
-					if err := os.WriteFile(filename, b, 0644); err != nil {
-						setStatus("[red]Failed to export: %v", err)
-					} else {
-						setStatus("[green]Exported %d rows to %s", len(currentData), filename)
-					}
+		// F2/F3 to save the editor query to a .sql file, or load one into the editor
+		if ev.Key() == tcell.KeyF2 || ev.Key() == tcell.KeyF3 {
+			showSQLFilePrompt(ev.Key() == tcell.KeyF2)
+			return nil
+		}
+
+		// F9 to reload config.json without restarting
+		if ev.Key() == tcell.KeyF9 {
+			applyReloadedConfig()
+			return nil
+		}
+
+		// F5 to check the connection now
+		if ev.Key() == tcell.KeyF5 {
+			connectionStatus.SetText("[yellow]●[white] Checking...")
+			go checkConnection(cfg, apiBase)
+			return nil
+		}
+
+		// Alt-1..Alt-5 to jump straight to a pane
+		if ev.Modifiers() == tcell.ModAlt && ev.Key() == tcell.KeyRune && ev.Rune() >= '1' && ev.Rune() <= '5' {
+			panes := []tview.Primitive{historyList, editor, resultsTable, detailView, rawView}
+			target := panes[ev.Rune()-'1']
+			app.SetFocus(target)
+			updateFocusColors(target)
+			return nil
+		}
+
+		// Alt-= / Alt-- to grow/shrink the focused pane, Alt-0 to reset the layout
+		if ev.Modifiers() == tcell.ModAlt && ev.Key() == tcell.KeyRune {
+			switch ev.Rune() {
+			case '=', '+':
+				resizeFocused(1)
+				return nil
+			case '-':
+				resizeFocused(-1)
+				return nil
+			case '0':
+				cfg.Layout = DefaultLayout()
+				applyLayout()
+				if err := saveConfig(cfg); err != nil {
+					setStatus("[red]Failed to save layout: %v", err)
 				} else {
-					setStatus("[red]Failed to marshal JSON: %v", err)
+					setStatus("[green]Layout reset")
 				}
+				return nil
+			}
+		}
+
+		// Alt-Up/Alt-Down in history to move the list/preview split
+		if ev.Modifiers() == tcell.ModAlt && app.GetFocus() == historyList && (ev.Key() == tcell.KeyUp || ev.Key() == tcell.KeyDown) {
+			if ev.Key() == tcell.KeyUp {
+				shiftHistorySplit(-1)
 			} else {
-				setStatus("[yellow]No results to export")
+				shiftHistorySplit(1)
 			}
 			return nil
 		}
 
+		// Alt-Left/Alt-Right to move the detail/raw split, Alt-Down to collapse one of them
+		// (not in the editor, where Alt-arrows move by word)
+		if ev.Modifiers() == tcell.ModAlt && app.GetFocus() != editor {
+			switch ev.Key() {
+			case tcell.KeyLeft:
+				shiftBottomSplit(-1)
+				return nil
+			case tcell.KeyRight:
+				shiftBottomSplit(1)
+				return nil
+			case tcell.KeyDown:
+				cycleBottomCollapse()
+				return nil
+			}
+		}
+
 		// Tab to cycle focus
 		if ev.Key() == tcell.KeyTab {
 			var nextFocus tview.Primitive
@@ -843,10 +7242,61 @@ func main() {
 			return nil
 		}
 
-		// Enter to run query from editor (auto-saves to history)
-		if ev.Key() == tcell.KeyEnter && app.GetFocus() == editor {
+		// Enter (or Ctrl-Enter, depending on enter_runs) to run query from editor (auto-saves to history).
+		// Terminals send Ctrl-Enter as Enter with Ctrl, or as Ctrl-J; Alt-Enter works as a fallback.
+		runPressed := ev.Key() == tcell.KeyEnter
+		if ctrlEnterRuns {
+			runPressed = (ev.Key() == tcell.KeyEnter && ev.Modifiers()&(tcell.ModCtrl|tcell.ModAlt) != 0) || ev.Key() == tcell.KeyCtrlJ
+		}
+		if runPressed && app.GetFocus() == editor {
 			q := editor.GetText()
-			runQuery(q)
+			confirmRun(q, false)
+			return nil
+		}
+
+		// Up on the editor's first line / Down at the end of its text to recall history entries
+		if app.GetFocus() == editor && ev.Modifiers() == 0 && (ev.Key() == tcell.KeyUp || ev.Key() == tcell.KeyDown) {
+			_, _, row, _ := editor.GetCursor()
+			if ev.Key() == tcell.KeyUp && row == 0 && recallHistory(1) {
+				return nil
+			}
+			if _, _, end := editor.GetSelection(); ev.Key() == tcell.KeyDown && end == editor.GetTextLength() && recallHistory(-1) {
+				return nil
+			}
+		}
+
+		// Ctrl-F in the editor to format the query
+		if ev.Modifiers() == tcell.ModCtrl && ev.Rune() == 'f' && app.GetFocus() == editor {
+			if q := strings.TrimSpace(editor.GetText()); q != "" {
+				editor.SetText(formatSQL(q), true)
+				setStatus("[green]Query formatted")
+			}
+			return nil
+		}
+
+		// Ctrl-S to save the editor query to history without running it
+		if ev.Modifiers() == tcell.ModCtrl && ev.Rune() == 's' {
+			q := strings.TrimSpace(editor.GetText())
+			if q == "" {
+				setStatus("[yellow]No query to save")
+				return nil
+			}
+			appendHistory(hist, q, cfg.Profile, cfg.MaxHistoryEntries)
+			if saved != hist {
+				appendHistory(saved, q, cfg.Profile, cfg.MaxHistoryEntries)
+			}
+			if err := saveHistory(saved); err != nil {
+				setStatus("[red]Failed to save history: %v", err)
+				return nil
+			}
+			refreshHistoryList()
+			setStatus("[green]Query saved to history")
+			return nil
+		}
+
+		// Ctrl-R to run the editor query, bypassing the response cache
+		if ev.Modifiers() == tcell.ModCtrl && ev.Rune() == 'r' {
+			confirmRun(editor.GetText(), true)
 			return nil
 		}
 		// Ctrl-Q to quit
@@ -858,13 +7308,52 @@ func main() {
 	})
 
 	// small help text
-	help := "[yellow]Shortcuts:[white] Enter Run  Tab Cycle  D Delete  Ctrl-E Export  Ctrl-Q Quit"
-	setStatus(help)
+	help := "[yellow]Shortcuts:[white] " + runKey + " Run  Tab Cycle  Alt-1..5 Pane  D Delete  Ctrl-E Export  Ctrl-O Browser  Ctrl-Q Quit"
+	setStatus("%s", help)
+
+	// flushState writes in-memory state to disk before exiting
+	flushState := func() {
+		if historySaveTimer != nil {
+			historySaveTimer.Stop()
+		}
+		if err := saveHistory(saved); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save history: %v\n", err)
+		}
+	}
+
+	// On SIGINT/SIGTERM/SIGHUP, stop the app cleanly so the terminal is restored
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		<-sigs
+		app.QueueUpdate(app.Stop)
+	}()
 
 	// start app
-	app.SetFocus(editor)
-	updateFocusColors(editor)
-	if err := app.SetRoot(flex, true).EnableMouse(true).Run(); err != nil {
+	var startFocus tview.Primitive = editor
+	if cfg.StartupFocus == "results" {
+		startFocus = resultsTable
+	}
+	app.SetFocus(startFocus)
+	updateFocusColors(startFocus)
+	if replayPath != "" {
+		editor.SetDisabled(true)
+		updateEditorTitle()
+		editor.SetPlaceholder("Browsing " + filepath.Base(replayPath) + " offline; running queries is disabled")
+		resultSource = "file " + filepath.Base(replayPath)
+		resultQueryView.SetText("[gray]" + tview.Escape(replayPath))
+		showResult(replayRows, "json", replayRaw, nil)
+		setStatus("[green]Opened %d rows from %s (read-only)", len(replayRows), tview.Escape(replayPath))
+		app.SetFocus(resultsTable)
+		updateFocusColors(resultsTable)
+	} else if q := strings.TrimSpace(cfg.StartupQuery); q != "" {
+		editor.SetText(q, true)
+		startupRun = true
+		confirmRun(q, false)
+	}
+	err = app.SetRoot(pages, true).EnableMouse(true).Run()
+	flushState()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running app: %v\n", err)
 		os.Exit(1)
 	}